@@ -1,20 +1,49 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"html/template"
-	"log"
 	"net/http"
+	"net/netip"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/payback159/notenschluessel/pkg/admin"
 	"github.com/payback159/notenschluessel/pkg/downloads"
 	"github.com/payback159/notenschluessel/pkg/handlers"
+	"github.com/payback159/notenschluessel/pkg/health"
 	"github.com/payback159/notenschluessel/pkg/logging"
+	"github.com/payback159/notenschluessel/pkg/metrics"
 	"github.com/payback159/notenschluessel/pkg/security"
 	"github.com/payback159/notenschluessel/pkg/session"
+	"github.com/payback159/notenschluessel/pkg/uploads"
 )
 
+// defaultShutdownTimeout bounds how long graceful shutdown waits for
+// in-flight requests to finish before giving up, unless overridden by the
+// SHUTDOWN_TIMEOUT environment variable.
+const defaultShutdownTimeout = 30 * time.Second
+
+// statusRecorder wraps a ResponseWriter to capture the status code a
+// handler ends up writing, so the instrumentation middleware can record the
+// status actually sent instead of assuming 200. Mirrors the recorder in
+// pkg/downloads/range.go, which can't be reused here since it's unexported.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	sr.status = status
+	sr.ResponseWriter.WriteHeader(status)
+}
+
 func main() {
 	// Initialize structured logging
 	logging.InitLogger()
@@ -23,12 +52,11 @@ func main() {
 		"version", "v1.0.0",
 		"environment", os.Getenv("ENV"))
 
-	// Check for health check flag
-	if len(os.Args) > 1 && os.Args[1] == "--health-check" {
-		// Simple health check - just exit with 0 if we can start
-		fmt.Println("OK")
-		os.Exit(0)
-	}
+	// rootCtx is canceled on SIGINT/SIGTERM (e.g. a Kubernetes pod
+	// eviction), and drives both the graceful shutdown below and every
+	// background goroutine that should stop alongside the server.
+	rootCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
 
 	// Load templates
 	templates := template.Must(template.ParseGlob("templates/*.html"))
@@ -58,10 +86,46 @@ func main() {
 	sessionStore := session.NewStore()
 	logging.LogInfo("Session store initialized")
 
-	// Initialize rate limiter
-	rateLimiter := security.NewRateLimiter()
+	// Initialize rate limiter. NewRateLimiterFromEnv honors RATE_LIMIT_BACKEND
+	// so it can share the same Redis instance as the session store
+	// (REDIS_URL) when running multiple replicas behind a load balancer.
+	rateLimiter := security.NewRateLimiterFromEnv()
+	rateLimiter.Start(rootCtx)
 	logging.LogInfo("Rate limiter initialized")
 
+	// Only honor forwarded-IP headers from a configured set of reverse
+	// proxies/CDNs, so a direct client can't spoof CF-Connecting-IP to
+	// dodge rate limiting. Unset in development, where requests come
+	// straight from the client.
+	if trusted := os.Getenv("TRUSTED_PROXIES"); trusted != "" {
+		var prefixes []netip.Prefix
+		for _, cidr := range strings.Split(trusted, ",") {
+			prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+			if err != nil {
+				logging.LogError("Invalid TRUSTED_PROXIES entry, skipping", err, "cidr", cidr)
+				continue
+			}
+			prefixes = append(prefixes, prefix)
+		}
+		security.SetTrustedProxies(prefixes)
+		logging.LogInfo("Trusted proxies configured", "count", len(prefixes))
+	}
+
+	// instrument records every request's method, status and duration via
+	// logging.LogHTTPRequest (which also feeds the
+	// notenschluessel_http_requests_total and
+	// notenschluessel_http_request_duration_seconds collectors), using
+	// pattern - the route registered in the mux, not the raw path - as the
+	// label so per-session paths don't each get their own time series.
+	instrument := func(pattern string, next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sr := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sr, r)
+			logging.LogHTTPRequest(r.Method, pattern, r.UserAgent(), security.GetClientIP(r), sr.status, time.Since(start))
+		})
+	}
+
 	securityHeaders := func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Prevent embedding in frames
@@ -102,37 +166,141 @@ func main() {
 	// Create multiplexer
 	mux := http.NewServeMux()
 
-	mux.Handle("/", securityHeaders(csrf.Handler(rateLimiter.RateLimitMiddleware(handler.HandleHome))))
+	// registeredRoutes tracks every pattern handed to the mux so
+	// /admin/routes can report them; http.ServeMux exposes no
+	// introspection API of its own.
+	var registeredRoutes []string
+	registerHandle := func(pattern string, h http.Handler) {
+		registeredRoutes = append(registeredRoutes, pattern)
+		mux.Handle(pattern, instrument(pattern, h))
+	}
 
-	mux.Handle("/download/grade-scale", securityHeaders(csrf.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	registerHandle("/", securityHeaders(csrf.Handler(rateLimiter.Register("upload", handler.HandleHome))))
+
+	registerHandle("/download", securityHeaders(csrf.Handler(rateLimiter.Register("download", func(w http.ResponseWriter, r *http.Request) {
+		downloads.HandleDownload(w, r, sessionStore)
+	}))))
+
+	registerHandle("/download/grade-scale", securityHeaders(csrf.Handler(rateLimiter.Register("download", func(w http.ResponseWriter, r *http.Request) {
 		downloads.HandleGradeScaleCSV(w, r, sessionStore)
 	}))))
-	mux.Handle("/download/student-results", securityHeaders(csrf.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	registerHandle("/download/student-results", securityHeaders(csrf.Handler(rateLimiter.Register("download", func(w http.ResponseWriter, r *http.Request) {
 		downloads.HandleStudentResultsCSV(w, r, sessionStore)
 	}))))
-	mux.Handle("/download/combined", securityHeaders(csrf.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	registerHandle("/download/combined", securityHeaders(csrf.Handler(rateLimiter.Register("download", func(w http.ResponseWriter, r *http.Request) {
 		downloads.HandleCombinedCSV(w, r, sessionStore)
 	}))))
 
 	// Excel download handlers with CSRF protection
-	mux.Handle("/download/grade-scale-excel", securityHeaders(csrf.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	registerHandle("/download/grade-scale-excel", securityHeaders(csrf.Handler(rateLimiter.Register("download", func(w http.ResponseWriter, r *http.Request) {
 		downloads.HandleGradeScaleExcel(w, r, sessionStore)
 	}))))
-	mux.Handle("/download/student-results-excel", securityHeaders(csrf.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	registerHandle("/download/student-results-excel", securityHeaders(csrf.Handler(rateLimiter.Register("download", func(w http.ResponseWriter, r *http.Request) {
 		downloads.HandleStudentResultsExcel(w, r, sessionStore)
 	}))))
-	mux.Handle("/download/combined-excel", securityHeaders(csrf.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	registerHandle("/download/combined-excel", securityHeaders(csrf.Handler(rateLimiter.Register("download", func(w http.ResponseWriter, r *http.Request) {
 		downloads.HandleCombinedExcel(w, r, sessionStore)
 	}))))
 
+	// PDF download handlers
+	registerHandle("/download/grade-scale-pdf", securityHeaders(csrf.Handler(rateLimiter.Register("download", func(w http.ResponseWriter, r *http.Request) {
+		downloads.HandleGradeScalePDF(w, r, sessionStore)
+	}))))
+	registerHandle("/download/student-results-pdf", securityHeaders(csrf.Handler(rateLimiter.Register("download", func(w http.ResponseWriter, r *http.Request) {
+		downloads.HandleStudentResultsPDF(w, r, sessionStore)
+	}))))
+	registerHandle("/download/combined-pdf", securityHeaders(csrf.Handler(rateLimiter.Register("download", func(w http.ResponseWriter, r *http.Request) {
+		downloads.HandleCombinedPDF(w, r, sessionStore)
+	}))))
+
+	// JSON/NDJSON download handlers, for round-tripping through the batch
+	// import API below.
+	registerHandle("/download/students.json", securityHeaders(csrf.Handler(rateLimiter.Register("download", func(w http.ResponseWriter, r *http.Request) {
+		downloads.HandleStudentsJSON(w, r, sessionStore)
+	}))))
+	registerHandle("/download/students.ndjson", securityHeaders(csrf.Handler(rateLimiter.Register("download", func(w http.ResponseWriter, r *http.Request) {
+		downloads.HandleStudentsNDJSON(w, r, sessionStore)
+	}))))
+
+	// Content-negotiated download endpoint for API clients: the view comes
+	// from the path, the format from ?format= or the Accept header. Kept
+	// under its own prefix rather than "/download/{view}" so it doesn't
+	// shadow the literal CSV routes above, which existing bookmarks/links
+	// rely on.
+	registerHandle("/download/view/{view}", securityHeaders(csrf.Handler(rateLimiter.Register("download", func(w http.ResponseWriter, r *http.Request) {
+		downloads.HandleDownloadByView(w, r, sessionStore)
+	}))))
+
+	// Bulk student import/export API. Shares the "upload" policy with
+	// HandleHome's CSV upload, since these do the same parse-and-calculate
+	// work a malicious client could otherwise loop unthrottled.
+	registerHandle("/api/students/batch", securityHeaders(csrf.Handler(rateLimiter.Register("upload", func(w http.ResponseWriter, r *http.Request) {
+		uploads.HandleStudentsBatchImport(w, r, sessionStore)
+	}))))
+	registerHandle("/api/students/batch-delete", securityHeaders(csrf.Handler(rateLimiter.Register("upload", func(w http.ResponseWriter, r *http.Request) {
+		uploads.HandleStudentsBatchDelete(w, r, sessionStore)
+	}))))
+
+	// Roster file import API (multipart CSV/Excel upload)
+	registerHandle("/api/students/import-csv", securityHeaders(csrf.Handler(rateLimiter.Register("upload", func(w http.ResponseWriter, r *http.Request) {
+		uploads.HandleStudentImportCSV(w, r, sessionStore)
+	}))))
+	registerHandle("/api/students/import-excel", securityHeaders(csrf.Handler(rateLimiter.Register("upload", func(w http.ResponseWriter, r *http.Request) {
+		uploads.HandleStudentImportExcel(w, r, sessionStore)
+	}))))
+
+	// Stateless JSON calculation API, for integration with external
+	// gradebook tools (LibreOffice macros, Excel Power Query, Moodle
+	// plugins) that calculate grade bounds without going through the HTML
+	// form or holding a session. HandleCalculateCSVAPI does the same
+	// CSV-parse-and-calculate work as HandleHome, so it shares the "upload"
+	// policy rather than going unthrottled.
+	registerHandle("/api/v1/calculate", securityHeaders(csrf.Handler(rateLimiter.Register("upload", handlers.HandleCalculateAPI))))
+	registerHandle("/api/v1/calculate/csv", securityHeaders(csrf.Handler(rateLimiter.Register("upload", handlers.HandleCalculateCSVAPI))))
+
+	registerHandle("/metrics", metrics.BearerAuth(metrics.Handler()))
+
+	// Liveness/readiness probes, unauthenticated like /metrics so
+	// Kubernetes' kubelet can reach them without credentials.
+	// /healthz only reflects in-process state (templates parsed); /readyz
+	// additionally checks the dependencies a real request needs. Templates
+	// are always true here because template.Must above already panics the
+	// process on a parse failure; the flag is threaded through anyway so
+	// main stays the single place that decides what "templates loaded"
+	// means.
+	registerHandle("/healthz", health.HandleLiveness(true))
+	registerHandle("/readyz", health.HandleReadiness(sessionStore, os.TempDir()))
+
+	// Admin/debug endpoints, protected by HTTP Basic auth (ADMIN_USER /
+	// ADMIN_PASSWORD). Registered last so registeredRoutes also includes
+	// every route registered above.
+	registerHandle("/admin/sessions", admin.BasicAuth(admin.HandleSessions(sessionStore)))
+	registerHandle("POST /admin/sessions/{id}/delete", admin.BasicAuth(admin.HandleSessionDelete(sessionStore)))
+	registerHandle("/admin/stats", admin.BasicAuth(admin.HandleStats()))
+	registerHandle("/admin/routes", admin.BasicAuth(admin.HandleRoutes(registeredRoutes)))
+	logging.LogInfo("Admin endpoints registered", "enabled", os.Getenv("ADMIN_USER") != "")
+
 	protectedHandler := mux
 
-	// Start periodic system statistics logging
+	// Heap, goroutine and GC metrics are now covered by the Go/process
+	// collectors client_golang registers on the default registry by
+	// default (see pkg/metrics/metrics.go), alongside
+	// logging.LogSystemStats' one-time startup snapshot below - no more
+	// ad-hoc periodic ticker needed just to refresh those numbers.
+
+	var backgroundTasks sync.WaitGroup
+	backgroundTasks.Add(1)
 	go func() {
-		ticker := time.NewTicker(10 * time.Minute)
+		defer backgroundTasks.Done()
+		ticker := time.NewTicker(30 * time.Second)
 		defer ticker.Stop()
-		for range ticker.C {
-			logging.LogSystemStats()
+		for {
+			select {
+			case <-rootCtx.Done():
+				return
+			case <-ticker.C:
+				metrics.SetActiveSessions(sessionStore.GetSessionCount())
+			}
 		}
 	}()
 
@@ -158,5 +326,58 @@ func main() {
 		"idle_timeout", "60s",
 		"max_header_bytes", "1MB")
 
-	log.Fatal(server.ListenAndServe())
+	serveErrs := make(chan error, 1)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErrs <- err
+			return
+		}
+		serveErrs <- nil
+	}()
+
+	// Block until either the server fails outright or a shutdown signal
+	// arrives, then drain in-flight requests within SHUTDOWN_TIMEOUT (default
+	// 30s) before tearing down the rate limiter and session store.
+	select {
+	case err := <-serveErrs:
+		if err != nil {
+			logging.LogError("Server failed to start", err)
+			os.Exit(1)
+		}
+		return
+	case <-rootCtx.Done():
+		logging.LogInfo("Shutdown signal received, starting graceful shutdown")
+	}
+
+	shutdownTimeout := defaultShutdownTimeout
+	if v := os.Getenv("SHUTDOWN_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			shutdownTimeout = d
+		} else {
+			logging.LogError("Invalid SHUTDOWN_TIMEOUT, using default", err, "value", v, "default", defaultShutdownTimeout.String())
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logging.LogError("Graceful shutdown did not complete cleanly", err, "timeout", shutdownTimeout.String())
+	}
+
+	// Wait for the active-sessions ticker goroutine to notice rootCtx is
+	// done before touching the session store, so it can't still be
+	// mid-call to it when Close() runs. The rate-limiter janitor started by
+	// rateLimiter.Start is not tracked by backgroundTasks.
+	backgroundTasks.Wait()
+
+	if err := rateLimiter.Close(); err != nil {
+		logging.LogError("Failed to close rate limiter", err)
+	}
+
+	if err := sessionStore.Close(); err != nil {
+		logging.LogError("Failed to close session store", err)
+	}
+
+	logging.LogInfo("Server stopped")
 }
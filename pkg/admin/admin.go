@@ -0,0 +1,161 @@
+// Package admin exposes basic-auth-protected debug endpoints for
+// inspecting a running instance: live sessions, registered routes and
+// runtime statistics.
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/payback159/notenschluessel/pkg/logging"
+	"github.com/payback159/notenschluessel/pkg/session"
+)
+
+// BasicAuth protects next with HTTP Basic credentials read from the
+// ADMIN_USER / ADMIN_PASSWORD environment variables. If either is unset,
+// the endpoint responds 404 rather than being left open with empty
+// credentials.
+func BasicAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		wantUser := os.Getenv("ADMIN_USER")
+		wantPass := os.Getenv("ADMIN_PASSWORD")
+		if wantUser == "" || wantPass == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		user, pass, ok := r.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(user), []byte(wantUser)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(pass), []byte(wantPass)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="admin"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// SessionSummary is the admin-facing view of a session: no page data
+// beyond what's needed to spot and, if necessary, evict a stuck session.
+type SessionSummary struct {
+	ID           string `json:"id"`
+	IDPrefix     string `json:"id_prefix"`
+	ExpiresAt    string `json:"expires_at"`
+	StudentCount int    `json:"student_count"`
+	MaxPoints    int    `json:"max_points"`
+}
+
+// idPrefix truncates a session ID so the admin UI doesn't leak full,
+// replayable session identifiers.
+func idPrefix(id string) string {
+	const n = 8
+	if len(id) <= n {
+		return id
+	}
+	return id[:n] + "..."
+}
+
+func wantsJSON(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "json" {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+var sessionsTemplate = template.Must(template.New("sessions").Parse(`<!DOCTYPE html>
+<html lang="de">
+<head><meta charset="utf-8"><title>Sessions</title></head>
+<body>
+<h1>Active Sessions ({{len .}})</h1>
+<table border="1" cellpadding="4" cellspacing="0">
+<tr><th>ID</th><th>Expires At</th><th>Students</th><th>Max Points</th><th></th></tr>
+{{range .}}<tr>
+<td>{{.IDPrefix}}</td><td>{{.ExpiresAt}}</td><td>{{.StudentCount}}</td><td>{{.MaxPoints}}</td>
+<td><form method="post" action="/admin/sessions/{{.ID}}/delete"><button type="submit">Delete</button></form></td>
+</tr>{{end}}
+</table>
+</body>
+</html>`))
+
+// HandleSessions lists live sessions from store as HTML (default) or JSON
+// (?format=json or an Accept: application/json request).
+func HandleSessions(store session.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var summaries []SessionSummary
+		store.Range(func(id string, data *session.Data) bool {
+			summaries = append(summaries, SessionSummary{
+				ID:           id,
+				IDPrefix:     idPrefix(id),
+				ExpiresAt:    data.ExpiresAt.Format("2006-01-02T15:04:05Z07:00"),
+				StudentCount: len(data.PageData.Students),
+				MaxPoints:    data.PageData.MaxPoints,
+			})
+			return true
+		})
+
+		sort.Slice(summaries, func(i, j int) bool { return summaries[i].ExpiresAt < summaries[j].ExpiresAt })
+
+		if wantsJSON(r) {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(summaries); err != nil {
+				logging.LogError("Failed to encode admin sessions response", err)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := sessionsTemplate.Execute(w, summaries); err != nil {
+			logging.LogError("Failed to render admin sessions page", err)
+		}
+	}
+}
+
+// HandleSessionDelete evicts the session identified by the {id} path
+// value. Registered as "POST /admin/sessions/{id}/delete".
+func HandleSessionDelete(store session.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if id == "" {
+			http.Error(w, "missing session id", http.StatusBadRequest)
+			return
+		}
+
+		store.Delete(id)
+		logging.LogInfo("Admin deleted session", "session_id_prefix", idPrefix(id))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleStats reports the process health snapshot that
+// logging.LogSystemStats otherwise only writes to the log.
+func HandleStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(logging.SystemStats()); err != nil {
+			logging.LogError("Failed to encode admin stats response", err)
+		}
+	}
+}
+
+// HandleRoutes reports the registered route patterns. Since
+// http.ServeMux exposes no introspection API, callers pass the patterns
+// they registered with the mux (e.g. collected at registration time in
+// main).
+func HandleRoutes(routes []string) http.HandlerFunc {
+	sorted := append([]string(nil), routes...)
+	sort.Strings(sorted)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(sorted); err != nil {
+			logging.LogError("Failed to encode admin routes response", err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/payback159/notenschluessel/pkg/logging"
+	"github.com/payback159/notenschluessel/pkg/models"
+	"github.com/payback159/notenschluessel/pkg/session"
+)
+
+func init() {
+	logging.InitLogger()
+}
+
+// --- BasicAuth ---
+
+func TestBasicAuth_DisabledWithoutCredentials(t *testing.T) {
+	os.Unsetenv("ADMIN_USER")
+	os.Unsetenv("ADMIN_PASSWORD")
+
+	h := BasicAuth(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/stats", nil)
+	h(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("want 404 when admin credentials are unset, got %d", w.Code)
+	}
+}
+
+func TestBasicAuth_RejectsWrongCredentials(t *testing.T) {
+	os.Setenv("ADMIN_USER", "admin")
+	os.Setenv("ADMIN_PASSWORD", "secret")
+	defer os.Unsetenv("ADMIN_USER")
+	defer os.Unsetenv("ADMIN_PASSWORD")
+
+	h := BasicAuth(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/stats", nil)
+	req.SetBasicAuth("admin", "wrong")
+	h(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("want 401 for wrong credentials, got %d", w.Code)
+	}
+}
+
+func TestBasicAuth_AcceptsCorrectCredentials(t *testing.T) {
+	os.Setenv("ADMIN_USER", "admin")
+	os.Setenv("ADMIN_PASSWORD", "secret")
+	defer os.Unsetenv("ADMIN_USER")
+	defer os.Unsetenv("ADMIN_PASSWORD")
+
+	h := BasicAuth(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/stats", nil)
+	req.SetBasicAuth("admin", "secret")
+	h(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("want 200 for correct credentials, got %d", w.Code)
+	}
+}
+
+// --- HandleSessions ---
+
+func TestHandleSessions_JSON(t *testing.T) {
+	store := session.NewMemoryStore()
+	store.Set("sid-1", models.PageData{MaxPoints: 100, Students: []models.Student{{Name: "Alice"}}})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/sessions?format=json", nil)
+	HandleSessions(store)(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type: got %s", ct)
+	}
+	if !strings.Contains(w.Body.String(), `"student_count":1`) {
+		t.Errorf("expected student_count in response, got %s", w.Body.String())
+	}
+}
+
+func TestHandleSessions_HTML(t *testing.T) {
+	store := session.NewMemoryStore()
+	store.Set("sid-1", models.PageData{MaxPoints: 100})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/sessions", nil)
+	HandleSessions(store)(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "<table") {
+		t.Error("expected an HTML table in the response")
+	}
+}
+
+// --- HandleSessionDelete ---
+
+func TestHandleSessionDelete(t *testing.T) {
+	store := session.NewMemoryStore()
+	store.Set("sid-to-delete", models.PageData{MaxPoints: 100})
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/sessions/sid-to-delete/delete", nil)
+	req.SetPathValue("id", "sid-to-delete")
+	w := httptest.NewRecorder()
+
+	HandleSessionDelete(store)(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("want 204, got %d", w.Code)
+	}
+	if _, exists := store.Get("sid-to-delete"); exists {
+		t.Error("session should have been deleted")
+	}
+}
+
+// --- HandleStats ---
+
+func TestHandleStats(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/stats", nil)
+	HandleStats()(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "goroutines") {
+		t.Error("expected goroutines field in stats response")
+	}
+}
+
+// --- HandleRoutes ---
+
+func TestHandleRoutes(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/routes", nil)
+	HandleRoutes([]string{"/download/combined", "/"})(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "/download/combined") {
+		t.Error("expected registered route in response")
+	}
+}
@@ -1,15 +1,21 @@
 package calculator
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"mime/multipart"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/xuri/excelize/v2"
+
 	"github.com/payback159/notenschluessel/pkg/logging"
 	"github.com/payback159/notenschluessel/pkg/models"
 	"github.com/payback159/notenschluessel/pkg/security"
@@ -47,7 +53,7 @@ func CalculateGradeBounds(maxPoints int, minPoints, breakPointPercent float64) [
 		{Grade: 2, LowerBound: lowerBound2, UpperBound: lowerBound1 - minPoints},
 		{Grade: 3, LowerBound: lowerBound3, UpperBound: lowerBound2 - minPoints},
 		{Grade: 4, LowerBound: lowerBound4, UpperBound: lowerBound3 - minPoints},
-		{Grade: 5, LowerBound: lowerBound5, UpperBound: lowerBound4 - minPoints},
+		{Grade: 5, LowerBound: lowerBound5, UpperBound: lowerBound4 - minPoints, Failing: true},
 	}
 
 	logging.LogInfo("Grade bounds calculated",
@@ -79,7 +85,10 @@ func CalculateGrade(points, lowerBound1, lowerBound2, lowerBound3, lowerBound4,
 	}
 }
 
-// ProcessStudents calculates grades for a list of students
+// ProcessStudents calculates grades for a list of students using exactly
+// the first 5 entries of gradeBounds as the standard school5 tiers; it is
+// not safe to call with CalculateTendencyBounds' 15-entry output (use
+// AssignGrades for that, or any other non-5-tier scheme).
 func ProcessStudents(students []models.Student, gradeBounds []models.GradeBound) []models.Student {
 	if len(gradeBounds) < 5 {
 		logging.LogError("Insufficient grade bounds for student processing", fmt.Errorf("need 5 grade bounds, got %d", len(gradeBounds)))
@@ -93,8 +102,10 @@ func ProcessStudents(students []models.Student, gradeBounds []models.GradeBound)
 	lowerBound5 := gradeBounds[4].LowerBound
 
 	for i := range students {
-		students[i].Grade = CalculateGrade(students[i].Points,
+		grade := CalculateGrade(students[i].Points,
 			lowerBound1, lowerBound2, lowerBound3, lowerBound4, lowerBound5)
+		students[i].Grade = grade
+		students[i].Tendency = gradeBounds[grade-1].Tendency
 	}
 
 	logging.LogInfo("Students processed",
@@ -124,11 +135,124 @@ func CalculateAverageGrade(students []models.Student) float64 {
 	return math.Round(average*100) / 100 // Round to 2 decimal places
 }
 
-// ParseCSVFile parses an uploaded CSV file and returns a list of students
+// delimiterSniffLen is how many bytes ParseCSVFile peeks to guess the CSV
+// delimiter, without needing to seek the underlying reader back afterward.
+const delimiterSniffLen = 4096
+
+// detectDelimiter guesses a CSV file's column delimiter from a sample of its
+// content: whichever of comma, semicolon or tab appears most often wins,
+// with comma as the default when none of them stand out.
+func detectDelimiter(sample []byte) rune {
+	content := string(sample)
+	comma := strings.Count(content, ",")
+	semicolon := strings.Count(content, ";")
+	tab := strings.Count(content, "\t")
+
+	delimiter, max := ',', comma
+	if semicolon > max {
+		delimiter, max = ';', semicolon
+	}
+	if tab > max {
+		delimiter = '\t'
+	}
+	return delimiter
+}
+
+// errSkipRow signals a header or blank row that parseStudentRecord wants
+// silently skipped, as opposed to a validation failure that should be
+// logged and counted toward skippedRows.
+var errSkipRow = errors.New("skip row")
+
+// parseStudentRecord validates and converts a single Name+Punkte row, the
+// shared row shape both ParseCSVFile and ParseXLSXFile consume (one from
+// encoding/csv, the other from excelize's GetRows).
+func parseStudentRecord(rowNum int, record []string) (models.Student, error) {
+	if rowNum == 0 && len(record) > 0 && strings.EqualFold(strings.TrimSpace(record[0]), "name") {
+		return models.Student{}, errSkipRow
+	}
+	if len(record) < 2 {
+		return models.Student{}, fmt.Errorf("row has fewer than 2 columns")
+	}
+
+	name := strings.TrimSpace(record[0])
+	pointsStr := strings.TrimSpace(record[1])
+
+	if name == "" && pointsStr == "" {
+		return models.Student{}, errSkipRow
+	}
+	if name == "" {
+		return models.Student{}, fmt.Errorf("empty name, points %q", pointsStr)
+	}
+
+	pointsStr = strings.ReplaceAll(pointsStr, ",", ".") // Handle German decimal format
+	points, err := strconv.ParseFloat(pointsStr, 64)
+	if err != nil {
+		return models.Student{}, fmt.Errorf("invalid points value %q: %w", record[1], err)
+	}
+	if points < 0 || points > 1000 {
+		return models.Student{}, fmt.Errorf("points value out of reasonable range: %v", points)
+	}
+
+	return models.Student{Name: security.SanitizeName(name), Points: points}, nil
+}
+
+// csvRow is one row read off the underlying csv.Reader by streamCSVRows'
+// background goroutine, alongside any error encoding/csv returned for it.
+type csvRow struct {
+	index  int
+	record []string
+	err    error
+}
+
+// streamCSVRows reads r as CSV on a background goroutine and sends each row
+// over the returned channel, so the caller can stop consuming - via the
+// returned stop func - without waiting for the whole file to be read first.
+// The channel is closed once the file is exhausted or stop is called.
+func streamCSVRows(r io.Reader, delimiter rune) (<-chan csvRow, func()) {
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+
+	out := make(chan csvRow, 32)
+	done := make(chan struct{})
+	var stopOnce sync.Once
+	// stop signals the goroutine to exit, then drains out until it closes
+	// out itself on its way out - so stop doesn't return until the
+	// goroutine is no longer touching r, letting the caller safely close
+	// the underlying file right after. Safe to call more than once.
+	stop := func() {
+		stopOnce.Do(func() { close(done) })
+		for range out {
+		}
+	}
+
+	go func() {
+		defer close(out)
+		for i := 0; ; i++ {
+			record, err := reader.Read()
+			if err == io.EOF {
+				return
+			}
+			select {
+			case out <- csvRow{index: i, record: record, err: err}:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return out, stop
+}
+
+// ParseCSVFile streams an uploaded CSV file and returns its students. The
+// delimiter (comma, semicolon or tab) is guessed from a 4KB Peek rather than
+// reading the file twice and seeking back, since some multipart backends
+// don't support seeking; rows are then streamed through a bounded channel to
+// a worker that validates and appends them, stopping the underlying reader
+// as soon as MaxStudents is reached instead of reading the rest of the file.
 func ParseCSVFile(fileHeader *multipart.FileHeader) ([]models.Student, error) {
 	start := time.Now()
 
-	// Validate file
 	if err := security.ValidateUpload(fileHeader); err != nil {
 		logging.LogSecurityEvent("Invalid file upload attempted", "medium",
 			"filename", fileHeader.Filename,
@@ -137,7 +261,6 @@ func ParseCSVFile(fileHeader *multipart.FileHeader) ([]models.Student, error) {
 		return nil, err
 	}
 
-	// Open file
 	file, err := fileHeader.Open()
 	if err != nil {
 		logging.LogError("Failed to open uploaded file", err,
@@ -147,108 +270,126 @@ func ParseCSVFile(fileHeader *multipart.FileHeader) ([]models.Student, error) {
 	}
 	defer file.Close()
 
-	// Parse CSV
-	reader := csv.NewReader(file)
-	// Try to detect delimiter: first try comma, then semicolon
-	firstBytes := make([]byte, 1024)
-	n, _ := file.Read(firstBytes)
-	if _, err := file.Seek(0, 0); err != nil {
-		return []models.Student{}, fmt.Errorf("failed to reset file pointer: %w", err)
-	}
-
-	delimiter := ','
-	if n > 0 {
-		content := string(firstBytes[:n])
-		commaCount := strings.Count(content, ",")
-		semicolonCount := strings.Count(content, ";")
-
-		// Use semicolon if it appears more frequently than comma
-		if semicolonCount > commaCount {
-			delimiter = ';'
-		}
-	}
-
-	reader.Comma = delimiter
+	reader := bufio.NewReader(file)
+	sample, _ := reader.Peek(delimiterSniffLen)
+	delimiter := detectDelimiter(sample)
 	logging.LogDebug("CSV delimiter detected", "delimiter", string(delimiter))
 
+	rows, stop := streamCSVRows(reader, delimiter)
+	defer stop()
+
 	var students []models.Student
 	var skippedRows int
 
-	for rowNum := 0; ; rowNum++ {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
+	for row := range rows {
+		if row.err != nil {
 			logging.LogWarn("CSV parsing error",
 				"filename", fileHeader.Filename,
-				"row", rowNum,
-				"error", err.Error())
+				"row", row.index,
+				"error", row.err.Error())
 			skippedRows++
 			continue
 		}
 
-		// Skip header row or empty rows
-		if rowNum == 0 && (len(record) > 0 && strings.ToLower(record[0]) == "name") {
+		student, err := parseStudentRecord(row.index, row.record)
+		if errors.Is(err, errSkipRow) {
 			continue
 		}
-
-		if len(record) < 2 {
+		if err != nil {
+			logging.LogWarn("Invalid row in CSV",
+				"filename", fileHeader.Filename,
+				"row", row.index,
+				"error", err.Error())
 			skippedRows++
 			continue
 		}
 
-		// Extract name and points
-		name := strings.TrimSpace(record[0])
-		pointsStr := strings.TrimSpace(record[1])
+		students = append(students, student)
 
-		// Skip empty rows
-		if name == "" && pointsStr == "" {
-			continue
+		if len(students) >= models.MaxStudents {
+			logging.LogWarn("Maximum student limit reached",
+				"filename", fileHeader.Filename,
+				"max_students", models.MaxStudents)
+			stop()
+			break
 		}
+	}
 
-		if name == "" {
-			logging.LogWarn("Empty name in CSV",
-				"filename", fileHeader.Filename,
-				"row", rowNum,
-				"points", pointsStr)
-			skippedRows++
+	duration := time.Since(start)
+	logging.LogCSVParseMetrics(len(students), skippedRows, duration)
+
+	if len(students) == 0 {
+		err := fmt.Errorf("no valid student data found in CSV file")
+		logging.LogError("CSV parsing resulted in no students", err,
+			"filename", fileHeader.Filename,
+			"total_rows_processed", skippedRows)
+		return nil, err
+	}
+
+	logging.LogFileOperation("csv_parse", fileHeader.Filename, fileHeader.Size, duration, true,
+		"total_students", len(students),
+		"skipped_rows", skippedRows)
+
+	return students, nil
+}
+
+// ParseXLSXFile parses the first worksheet of an uploaded .xlsx file and
+// returns its students, applying the same row validation as ParseCSVFile.
+func ParseXLSXFile(fileHeader *multipart.FileHeader) ([]models.Student, error) {
+	start := time.Now()
+
+	if err := security.ValidateUploadExt(fileHeader, ".xlsx"); err != nil {
+		logging.LogSecurityEvent("Invalid file upload attempted", "medium",
+			"filename", fileHeader.Filename,
+			"size", fileHeader.Size,
+			"error", err.Error())
+		return nil, err
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		logging.LogError("Failed to open uploaded file", err,
+			"filename", fileHeader.Filename,
+			"size", fileHeader.Size)
+		return nil, fmt.Errorf("could not open file: %v", err)
+	}
+	defer file.Close()
+
+	f, err := excelize.OpenReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not open Excel file: %w", err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("excel file has no worksheet")
+	}
+
+	rows, err := f.GetRows(sheets[0])
+	if err != nil {
+		return nil, fmt.Errorf("could not read worksheet: %w", err)
+	}
+
+	var students []models.Student
+	var skippedRows int
+
+	for i, record := range rows {
+		student, err := parseStudentRecord(i, record)
+		if errors.Is(err, errSkipRow) {
 			continue
 		}
-
-		// Parse points
-		pointsStr = strings.ReplaceAll(pointsStr, ",", ".") // Handle German decimal format
-		points, err := strconv.ParseFloat(pointsStr, 64)
 		if err != nil {
-			logging.LogWarn("Invalid points value in CSV",
+			logging.LogWarn("Invalid row in Excel file",
 				"filename", fileHeader.Filename,
-				"row", rowNum,
-				"name", name,
-				"points_str", pointsStr,
+				"row", i,
 				"error", err.Error())
 			skippedRows++
 			continue
 		}
 
-		// Validate points (reasonable range)
-		if points < 0 || points > 1000 {
-			logging.LogWarn("Points value out of reasonable range",
-				"filename", fileHeader.Filename,
-				"row", rowNum,
-				"name", name,
-				"points", points)
-			skippedRows++
-			continue
-		}
-
-		// Sanitize name and add student
-		sanitizedName := security.SanitizeName(name)
-		students = append(students, models.Student{
-			Name:   sanitizedName,
-			Points: points,
-		})
+		students = append(students, student)
 
-		// Limit number of students for security
 		if len(students) >= models.MaxStudents {
 			logging.LogWarn("Maximum student limit reached",
 				"filename", fileHeader.Filename,
@@ -260,16 +401,43 @@ func ParseCSVFile(fileHeader *multipart.FileHeader) ([]models.Student, error) {
 	duration := time.Since(start)
 
 	if len(students) == 0 {
-		err := fmt.Errorf("no valid student data found in CSV file")
-		logging.LogError("CSV parsing resulted in no students", err,
+		err := fmt.Errorf("no valid student data found in Excel file")
+		logging.LogError("Excel parsing resulted in no students", err,
 			"filename", fileHeader.Filename,
 			"total_rows_processed", skippedRows)
 		return nil, err
 	}
 
-	logging.LogFileOperation("csv_parse", fileHeader.Filename, fileHeader.Size, duration, true,
+	logging.LogFileOperation("xlsx_parse", fileHeader.Filename, fileHeader.Size, duration, true,
 		"total_students", len(students),
 		"skipped_rows", skippedRows)
 
 	return students, nil
 }
+
+// xlsxSignature is the ZIP local-file-header magic bytes every .xlsx file
+// starts with (it's a ZIP container), used to tell an Excel upload from a
+// CSV one regardless of what the client claims its Content-Type/filename is.
+var xlsxSignature = []byte("PK\x03\x04")
+
+// ParseStudents dispatches an uploaded roster to ParseXLSXFile or
+// ParseCSVFile based on the file's actual content rather than its
+// client-supplied Content-Type, which can't be trusted. The chosen parser
+// still applies its own filename-extension check, so a genuine .xlsx file
+// uploaded under a non-".xlsx" name is rejected rather than silently
+// accepted - treat that as a feature, not a bug: it catches accidental or
+// deliberate extension spoofing.
+func ParseStudents(fileHeader *multipart.FileHeader) ([]models.Student, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("could not open file: %w", err)
+	}
+	sample := make([]byte, len(xlsxSignature))
+	_, readErr := io.ReadFull(file, sample)
+	file.Close()
+
+	if readErr == nil && bytes.Equal(sample, xlsxSignature) {
+		return ParseXLSXFile(fileHeader)
+	}
+	return ParseCSVFile(fileHeader)
+}
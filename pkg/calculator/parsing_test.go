@@ -0,0 +1,229 @@
+package calculator
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/payback159/notenschluessel/pkg/models"
+	"github.com/xuri/excelize/v2"
+)
+
+// newUploadFileHeader builds a *multipart.FileHeader for content the same
+// way an HTTP upload would produce one, since multipart.FileHeader has no
+// exported constructor.
+func newUploadFileHeader(t *testing.T, filename string, content []byte) *multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("csvFile", filename)
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		t.Fatalf("write part: %v", err)
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatalf("close writer: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if err := req.ParseMultipartForm(10 << 20); err != nil {
+		t.Fatalf("ParseMultipartForm: %v", err)
+	}
+
+	_, fileHeader, err := req.FormFile("csvFile")
+	if err != nil {
+		t.Fatalf("FormFile: %v", err)
+	}
+	return fileHeader
+}
+
+func newTestXLSXBytes(t *testing.T, rows [][]string) []byte {
+	t.Helper()
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := f.GetSheetName(0)
+	for i, row := range rows {
+		for j, val := range row {
+			cell, err := excelize.CoordinatesToCellName(j+1, i+1)
+			if err != nil {
+				t.Fatalf("CoordinatesToCellName: %v", err)
+			}
+			f.SetCellValue(sheet, cell, val)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatalf("write xlsx: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// --- detectDelimiter ---
+
+func TestDetectDelimiter(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    rune
+	}{
+		{"comma", "Name,Punkte\nAlice,90\nBob,60\n", ','},
+		{"semicolon", "Name;Punkte\nAlice;90\nBob;60\n", ';'},
+		{"tab", "Name\tPunkte\nAlice\t90\nBob\t60\n", '\t'},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectDelimiter([]byte(tt.content)); got != tt.want {
+				t.Errorf("detectDelimiter(%q): want %q, got %q", tt.content, tt.want, got)
+			}
+		})
+	}
+}
+
+// --- ParseCSVFile ---
+
+func TestParseCSVFile_Comma(t *testing.T) {
+	fh := newUploadFileHeader(t, "students.csv", []byte("Name,Punkte\nAlice,90\nBob,60\n"))
+
+	students, err := ParseCSVFile(fh)
+	if err != nil {
+		t.Fatalf("ParseCSVFile: %v", err)
+	}
+	if len(students) != 2 {
+		t.Fatalf("expected 2 students, got %d", len(students))
+	}
+	if students[0].Name != "Alice" || students[0].Points != 90 {
+		t.Errorf("unexpected first student: %+v", students[0])
+	}
+}
+
+func TestParseCSVFile_Semicolon(t *testing.T) {
+	fh := newUploadFileHeader(t, "students.csv", []byte("Name;Punkte\nAlice;90\nBob;60\n"))
+
+	students, err := ParseCSVFile(fh)
+	if err != nil {
+		t.Fatalf("ParseCSVFile: %v", err)
+	}
+	if len(students) != 2 {
+		t.Fatalf("expected 2 students, got %d", len(students))
+	}
+}
+
+func TestParseCSVFile_Tab(t *testing.T) {
+	fh := newUploadFileHeader(t, "students.csv", []byte("Name\tPunkte\nAlice\t90\nBob\t60\n"))
+
+	students, err := ParseCSVFile(fh)
+	if err != nil {
+		t.Fatalf("ParseCSVFile: %v", err)
+	}
+	if len(students) != 2 {
+		t.Fatalf("expected 2 students, got %d", len(students))
+	}
+}
+
+func TestParseCSVFile_StopsAtMaxStudents(t *testing.T) {
+	var content bytes.Buffer
+	content.WriteString("Name,Punkte\n")
+	for i := 0; i < models.MaxStudents+50; i++ {
+		content.WriteString("Student,50\n")
+	}
+
+	fh := newUploadFileHeader(t, "students.csv", content.Bytes())
+
+	students, err := ParseCSVFile(fh)
+	if err != nil {
+		t.Fatalf("ParseCSVFile: %v", err)
+	}
+	if len(students) != models.MaxStudents {
+		t.Errorf("expected exactly %d students, got %d", models.MaxStudents, len(students))
+	}
+}
+
+func TestParseCSVFile_SkipsInvalidRows(t *testing.T) {
+	fh := newUploadFileHeader(t, "students.csv", []byte("Name,Punkte\nAlice,90\n,70\nBob,not-a-number\nCarol,60\n"))
+
+	students, err := ParseCSVFile(fh)
+	if err != nil {
+		t.Fatalf("ParseCSVFile: %v", err)
+	}
+	if len(students) != 2 {
+		t.Fatalf("expected 2 valid students, got %d", len(students))
+	}
+}
+
+func TestParseCSVFile_NoValidStudents(t *testing.T) {
+	fh := newUploadFileHeader(t, "students.csv", []byte("Name,Punkte\n"))
+
+	if _, err := ParseCSVFile(fh); err == nil {
+		t.Error("expected error when no valid students are found")
+	}
+}
+
+// --- ParseXLSXFile ---
+
+func TestParseXLSXFile_ValidData(t *testing.T) {
+	xlsx := newTestXLSXBytes(t, [][]string{
+		{"Name", "Punkte"},
+		{"Alice", "90"},
+		{"Bob", "60"},
+	})
+
+	fh := newUploadFileHeader(t, "students.xlsx", xlsx)
+
+	students, err := ParseXLSXFile(fh)
+	if err != nil {
+		t.Fatalf("ParseXLSXFile: %v", err)
+	}
+	if len(students) != 2 {
+		t.Fatalf("expected 2 students, got %d", len(students))
+	}
+}
+
+func TestParseXLSXFile_WrongExtension(t *testing.T) {
+	xlsx := newTestXLSXBytes(t, [][]string{{"Name", "Punkte"}, {"Alice", "90"}})
+	fh := newUploadFileHeader(t, "students.csv", xlsx)
+
+	if _, err := ParseXLSXFile(fh); err == nil {
+		t.Error("expected error for non-.xlsx filename")
+	}
+}
+
+// --- ParseStudents ---
+
+func TestParseStudents_DispatchesCSV(t *testing.T) {
+	fh := newUploadFileHeader(t, "students.csv", []byte("Name,Punkte\nAlice,90\nBob,60\n"))
+
+	students, err := ParseStudents(fh)
+	if err != nil {
+		t.Fatalf("ParseStudents: %v", err)
+	}
+	if len(students) != 2 {
+		t.Fatalf("expected 2 students, got %d", len(students))
+	}
+}
+
+func TestParseStudents_DispatchesXLSX(t *testing.T) {
+	xlsx := newTestXLSXBytes(t, [][]string{
+		{"Name", "Punkte"},
+		{"Alice", "90"},
+		{"Bob", "60"},
+	})
+	fh := newUploadFileHeader(t, "students.xlsx", xlsx)
+
+	students, err := ParseStudents(fh)
+	if err != nil {
+		t.Fatalf("ParseStudents: %v", err)
+	}
+	if len(students) != 2 {
+		t.Fatalf("expected 2 students, got %d", len(students))
+	}
+}
@@ -0,0 +1,218 @@
+package calculator
+
+import (
+	"math"
+
+	"github.com/payback159/notenschluessel/pkg/models"
+)
+
+// GradingScheme computes grade boundaries for a points range under a
+// particular grading standard, so the HTTP layer can let a user pick a
+// scheme by name instead of being locked into the German breakpoint model
+// CalculateGradeBounds implements directly.
+type GradingScheme interface {
+	// Name is the registry key used in the "scheme" form/query parameter.
+	Name() string
+	// ComputeBounds returns one GradeBound per grade tier, best grade
+	// first, covering the full range from 0 to maxPoints. params carries
+	// scheme-specific tuning (e.g. "breakPointPercent" for SchoolScale5);
+	// a missing key falls back to the scheme's own default.
+	ComputeBounds(maxPoints int, minPoints float64, params map[string]float64) []models.GradeBound
+}
+
+// schemeRegistry holds every built-in GradingScheme keyed by its own Name().
+var schemeRegistry = map[string]GradingScheme{}
+
+// registerScheme adds a scheme to the registry under its own Name().
+func registerScheme(s GradingScheme) {
+	schemeRegistry[s.Name()] = s
+}
+
+func init() {
+	registerScheme(SchoolScale5{})
+	registerScheme(SchoolScale6{})
+	registerScheme(IHKPoints100{})
+	registerScheme(AustrianScale5{})
+	registerScheme(CambridgeABCDE{})
+	registerScheme(LinearNPoints{})
+}
+
+// ResolveScheme looks up a registered GradingScheme by name, defaulting to
+// SchoolScale5 (the original breakpoint model) for an empty or unknown name.
+func ResolveScheme(name string) GradingScheme {
+	if s, ok := schemeRegistry[name]; ok {
+		return s
+	}
+	return SchoolScale5{}
+}
+
+// roundToStep rounds value to the nearest multiple of step, mirroring
+// CalculateGradeBounds' own rounding so every scheme lines up on the same
+// point increments a teacher enters.
+func roundToStep(value, step float64) float64 {
+	if step <= 0 {
+		return value
+	}
+	return math.Round(value/step) * step
+}
+
+// boundsFromPercents builds grade bounds from lower-bound percentages of
+// maxPoints, best grade first, rounding every lower bound to the nearest
+// minPoints increment. It's shared by the fixed-anchor schemes (schemes
+// whose percentages don't depend on params).
+func boundsFromPercents(maxPoints int, minPoints float64, percents []float64) []models.GradeBound {
+	lowerBounds := make([]float64, len(percents))
+	for i, p := range percents {
+		lowerBounds[i] = roundToStep(float64(maxPoints)*p/100.0, minPoints)
+	}
+
+	bounds := make([]models.GradeBound, len(percents))
+	for i := range percents {
+		upper := float64(maxPoints)
+		if i > 0 {
+			upper = lowerBounds[i-1] - minPoints
+		}
+		bounds[i] = models.GradeBound{Grade: i + 1, LowerBound: lowerBounds[i], UpperBound: upper, Failing: i == len(percents)-1}
+	}
+	return bounds
+}
+
+// AssignGrades sets each student's Grade (and Tendency, if the bound it
+// matched carries one) to that of the best bound whose LowerBound their
+// points meet. Unlike ProcessStudents, it isn't limited to a fixed
+// five-tier scale, so it works with any GradingScheme's bound count,
+// including CalculateTendencyBounds' fifteen tendency tiers; bounds must be
+// ordered best grade first, as ComputeBounds returns them.
+func AssignGrades(students []models.Student, bounds []models.GradeBound) []models.Student {
+	for i := range students {
+		grade := 0
+		tendency := ""
+		for _, b := range bounds {
+			if students[i].Points >= b.LowerBound {
+				grade = b.Grade
+				tendency = b.Tendency
+				break
+			}
+		}
+		students[i].Grade = grade
+		students[i].Tendency = tendency
+	}
+	return students
+}
+
+// SchoolScale5 is the German 5-tier school scale (1 best, 5 worst) anchored
+// on a configurable breakpoint. It wraps CalculateGradeBounds so selecting
+// it by name produces exactly the same bounds as calling that function
+// directly, and is the scheme used when no "scheme" parameter is given.
+type SchoolScale5 struct{}
+
+func (SchoolScale5) Name() string { return "school5" }
+
+func (SchoolScale5) ComputeBounds(maxPoints int, minPoints float64, params map[string]float64) []models.GradeBound {
+	breakPointPercent := params["breakPointPercent"]
+	if breakPointPercent <= 0 {
+		breakPointPercent = 50
+	}
+	return CalculateGradeBounds(maxPoints, minPoints, breakPointPercent)
+}
+
+// SchoolScale6 is the German 6-tier school scale, extending SchoolScale5's
+// breakpoint model with an additional "ungenügend" tier below grade 5.
+type SchoolScale6 struct{}
+
+func (SchoolScale6) Name() string { return "school6" }
+
+func (SchoolScale6) ComputeBounds(maxPoints int, minPoints float64, params map[string]float64) []models.GradeBound {
+	breakPointPercent := params["breakPointPercent"]
+	if breakPointPercent <= 0 {
+		breakPointPercent = 50
+	}
+	breakPointAbsolute := float64(maxPoints) * (breakPointPercent / 100.0)
+
+	lowerBounds := []float64{
+		float64(maxPoints) * 0.85,
+		breakPointAbsolute,
+		breakPointAbsolute * 0.6,
+		breakPointAbsolute * 0.4,
+		breakPointAbsolute * 0.2,
+		0,
+	}
+	for i := range lowerBounds {
+		lowerBounds[i] = roundToStep(lowerBounds[i], minPoints)
+	}
+
+	bounds := make([]models.GradeBound, len(lowerBounds))
+	for i := range lowerBounds {
+		upper := float64(maxPoints)
+		if i > 0 {
+			upper = lowerBounds[i-1] - minPoints
+		}
+		// The bottom two tiers ("mangelhaft" and "ungenügend") both fail,
+		// unlike SchoolScale5 where only the single worst tier does.
+		bounds[i] = models.GradeBound{Grade: i + 1, LowerBound: lowerBounds[i], UpperBound: upper, Failing: i >= len(lowerBounds)-2}
+	}
+	return bounds
+}
+
+// IHKPoints100 is the German IHK (Industrie- und Handelskammer) 100-point
+// scheme: sehr gut >= 92, gut >= 81, befriedigend >= 67, ausreichend >= 50,
+// mangelhaft >= 30, ungenügend below. Its anchors are fixed by the IHK
+// standard, so params is ignored.
+type IHKPoints100 struct{}
+
+func (IHKPoints100) Name() string { return "ihk100" }
+
+func (IHKPoints100) ComputeBounds(maxPoints int, minPoints float64, _ map[string]float64) []models.GradeBound {
+	return boundsFromPercents(maxPoints, minPoints, []float64{92, 81, 67, 50, 30, 0})
+}
+
+// AustrianScale5 is the Austrian 5-tier school scale (Sehr gut .. Nicht
+// genügend). Its anchors are fixed by convention, so params is ignored.
+type AustrianScale5 struct{}
+
+func (AustrianScale5) Name() string { return "austrian5" }
+
+func (AustrianScale5) ComputeBounds(maxPoints int, minPoints float64, _ map[string]float64) []models.GradeBound {
+	return boundsFromPercents(maxPoints, minPoints, []float64{87, 73, 60, 50, 0})
+}
+
+// CambridgeABCDE is the Cambridge International A-E scale, with the letter
+// grades represented as Grade 1 (A, best) through Grade 5 (E, worst). Its
+// anchors are fixed by convention, so params is ignored.
+type CambridgeABCDE struct{}
+
+func (CambridgeABCDE) Name() string { return "cambridge" }
+
+func (CambridgeABCDE) ComputeBounds(maxPoints int, minPoints float64, _ map[string]float64) []models.GradeBound {
+	return boundsFromPercents(maxPoints, minPoints, []float64{80, 70, 60, 50, 0})
+}
+
+// LinearNPoints divides the full point range into params["n"] equally sized
+// tiers (default 5), for schools that don't follow any named grading
+// standard at all.
+type LinearNPoints struct{}
+
+func (LinearNPoints) Name() string { return "linear" }
+
+func (LinearNPoints) ComputeBounds(maxPoints int, minPoints float64, params map[string]float64) []models.GradeBound {
+	n := int(params["n"])
+	if n <= 0 {
+		n = 5
+	}
+
+	lowerBounds := make([]float64, n)
+	for i := 0; i < n; i++ {
+		lowerBounds[i] = roundToStep(float64(maxPoints)*float64(n-i-1)/float64(n), minPoints)
+	}
+	lowerBounds[n-1] = 0
+
+	bounds := make([]models.GradeBound, n)
+	for i := range lowerBounds {
+		upper := float64(maxPoints)
+		if i > 0 {
+			upper = lowerBounds[i-1] - minPoints
+		}
+		bounds[i] = models.GradeBound{Grade: i + 1, LowerBound: lowerBounds[i], UpperBound: upper, Failing: i == n-1}
+	}
+	return bounds
+}
@@ -0,0 +1,138 @@
+package calculator
+
+import (
+	"testing"
+
+	"github.com/payback159/notenschluessel/pkg/models"
+)
+
+// assertValidBounds checks the invariants every GradingScheme's bounds must
+// hold regardless of tier count: grades numbered 1..n best first, no
+// negative lower bounds, and no overlapping ranges.
+func assertValidBounds(t *testing.T, name string, bounds []models.GradeBound) {
+	t.Helper()
+
+	for i, b := range bounds {
+		if b.Grade != i+1 {
+			t.Errorf("%s: bound[%d].Grade = %d, want %d", name, i, b.Grade, i+1)
+		}
+		if b.LowerBound < 0 {
+			t.Errorf("%s: grade %d has negative lower bound %.2f", name, b.Grade, b.LowerBound)
+		}
+	}
+
+	for i := 1; i < len(bounds); i++ {
+		if bounds[i].LowerBound > bounds[i-1].LowerBound {
+			t.Errorf("%s: grade %d lower bound (%.2f) > grade %d lower bound (%.2f)",
+				name, bounds[i].Grade, bounds[i].LowerBound, bounds[i-1].Grade, bounds[i-1].LowerBound)
+		}
+		if bounds[i].UpperBound >= bounds[i-1].LowerBound {
+			t.Errorf("%s: grade %d upper bound (%.2f) >= grade %d lower bound (%.2f), ranges overlap",
+				name, bounds[i].Grade, bounds[i].UpperBound, bounds[i-1].Grade, bounds[i-1].LowerBound)
+		}
+	}
+}
+
+func TestResolveScheme(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"school5", "school5"},
+		{"school6", "school6"},
+		{"ihk100", "ihk100"},
+		{"austrian5", "austrian5"},
+		{"cambridge", "cambridge"},
+		{"linear", "linear"},
+		{"", "school5"},
+		{"unknown", "school5"},
+	}
+	for _, c := range cases {
+		if got := ResolveScheme(c.name).Name(); got != c.want {
+			t.Errorf("ResolveScheme(%q).Name() = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSchoolScale5_MatchesCalculateGradeBounds(t *testing.T) {
+	viaScheme := SchoolScale5{}.ComputeBounds(100, 0.5, map[string]float64{"breakPointPercent": 50})
+	direct := CalculateGradeBounds(100, 0.5, 50)
+
+	if len(viaScheme) != len(direct) {
+		t.Fatalf("bound count mismatch: scheme %d, direct %d", len(viaScheme), len(direct))
+	}
+	for i := range direct {
+		if viaScheme[i] != direct[i] {
+			t.Errorf("bound[%d]: scheme %+v, direct %+v", i, viaScheme[i], direct[i])
+		}
+	}
+}
+
+func TestBuiltinSchemes_ProduceValidBounds(t *testing.T) {
+	schemes := []GradingScheme{
+		SchoolScale5{}, SchoolScale6{}, IHKPoints100{}, AustrianScale5{}, CambridgeABCDE{}, LinearNPoints{},
+	}
+	for _, s := range schemes {
+		bounds := s.ComputeBounds(100, 1, map[string]float64{"breakPointPercent": 50})
+		if len(bounds) == 0 {
+			t.Errorf("%s: ComputeBounds returned no bounds", s.Name())
+			continue
+		}
+		if bounds[0].UpperBound != 100 {
+			t.Errorf("%s: grade 1 upper bound = %.2f, want 100", s.Name(), bounds[0].UpperBound)
+		}
+		assertValidBounds(t, s.Name(), bounds)
+	}
+}
+
+func TestSchoolScale6_HasSixTiers(t *testing.T) {
+	bounds := SchoolScale6{}.ComputeBounds(100, 0.5, map[string]float64{"breakPointPercent": 50})
+	if len(bounds) != 6 {
+		t.Fatalf("expected 6 grade bounds, got %d", len(bounds))
+	}
+	if bounds[5].LowerBound != 0 {
+		t.Errorf("grade 6 lower bound = %.2f, want 0", bounds[5].LowerBound)
+	}
+}
+
+func TestLinearNPoints_RespectsTierCount(t *testing.T) {
+	bounds := LinearNPoints{}.ComputeBounds(100, 1, map[string]float64{"n": 4})
+	if len(bounds) != 4 {
+		t.Fatalf("expected 4 grade bounds, got %d", len(bounds))
+	}
+	if bounds[3].LowerBound != 0 {
+		t.Errorf("last grade lower bound = %.2f, want 0", bounds[3].LowerBound)
+	}
+}
+
+func TestLinearNPoints_DefaultsToFiveTiers(t *testing.T) {
+	bounds := LinearNPoints{}.ComputeBounds(100, 1, map[string]float64{})
+	if len(bounds) != 5 {
+		t.Fatalf("expected default of 5 grade bounds, got %d", len(bounds))
+	}
+}
+
+func TestCambridgeABCDE_LowestTierCoversZero(t *testing.T) {
+	bounds := CambridgeABCDE{}.ComputeBounds(100, 0.5, nil)
+	if bounds[len(bounds)-1].LowerBound != 0 {
+		t.Errorf("lowest tier lower bound = %.2f, want 0", bounds[len(bounds)-1].LowerBound)
+	}
+}
+
+func TestAssignGrades_WorksWithAnyTierCount(t *testing.T) {
+	bounds := IHKPoints100{}.ComputeBounds(100, 0.5, nil)
+	students := []models.Student{
+		{Name: "Alice", Points: 95},
+		{Name: "Bob", Points: 70},
+		{Name: "Charlie", Points: 10},
+	}
+
+	result := AssignGrades(students, bounds)
+
+	if result[0].Grade != 1 {
+		t.Errorf("Alice (95 pts) should be grade 1, got %d", result[0].Grade)
+	}
+	if result[2].Grade != 6 {
+		t.Errorf("Charlie (10 pts) should be grade 6, got %d", result[2].Grade)
+	}
+}
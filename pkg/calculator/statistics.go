@@ -0,0 +1,147 @@
+package calculator
+
+import (
+	"math"
+	"sort"
+
+	"github.com/payback159/notenschluessel/pkg/models"
+)
+
+// worstGrade returns the highest grade number in play: the last bound's
+// grade, or, if no bounds were passed, the worst grade actually assigned.
+func worstGrade(students []models.Student, bounds []models.GradeBound) int {
+	worst := 0
+	for _, b := range bounds {
+		if b.Grade > worst {
+			worst = b.Grade
+		}
+	}
+	if worst > 0 {
+		return worst
+	}
+	for _, s := range students {
+		if s.Grade > worst {
+			worst = s.Grade
+		}
+	}
+	return worst
+}
+
+// failingGrades returns the set of grade numbers that count as a fail.
+// Schemes mark their own failing tiers on each GradeBound (school6, for
+// example, fails both its bottom two tiers, not just the worst one); when
+// bounds carries no such information - nil bounds, or a caller that built
+// its own bounds without setting Failing - it falls back to the old
+// single-worst-tier assumption so callers that don't pass a scheme's bounds
+// keep working.
+func failingGrades(students []models.Student, bounds []models.GradeBound) map[int]bool {
+	failing := make(map[int]bool)
+	for _, b := range bounds {
+		if b.Failing {
+			failing[b.Grade] = true
+		}
+	}
+	if len(failing) > 0 {
+		return failing
+	}
+
+	if worst := worstGrade(students, bounds); worst > 0 {
+		failing[worst] = true
+	}
+	return failing
+}
+
+// median returns the middle value of a sorted copy of values; for an even
+// count it averages the two middle values.
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// roundTo2 rounds to 2 decimal places, matching CalculateAverageGrade's
+// rounding.
+func roundTo2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// ClassStatistics computes the class-wide statistics table: point spread
+// (median, standard deviation, min/max), the per-grade distribution, a
+// credit-weighted Notendurchschnitt, and the pass/fail split. bounds should
+// be the same gradeBounds AssignGrades used, so the fail tiers are derived
+// from the active scheme (see failingGrades) instead of assuming only the
+// worst grade number fails.
+func ClassStatistics(students []models.Student, bounds []models.GradeBound) models.ClassStats {
+	stats := models.ClassStats{
+		GradeCounts:      map[int]int{},
+		GradePercentages: map[int]float64{},
+	}
+	if len(students) == 0 {
+		return stats
+	}
+
+	failing := failingGrades(students, bounds)
+
+	points := make([]float64, len(students))
+	stats.MinPoints = students[0].Points
+	stats.MaxPoints = students[0].Points
+
+	sumPoints := 0.0
+	weightedSum := 0.0
+	weightTotal := 0.0
+	passCount := 0
+
+	for i, s := range students {
+		points[i] = s.Points
+		if s.Points < stats.MinPoints {
+			stats.MinPoints = s.Points
+		}
+		if s.Points > stats.MaxPoints {
+			stats.MaxPoints = s.Points
+		}
+		sumPoints += s.Points
+
+		stats.GradeCounts[s.Grade]++
+
+		weight := s.Credits
+		if weight <= 0 {
+			weight = 1
+		}
+		weightedSum += float64(s.Grade) * weight
+		weightTotal += weight
+
+		if s.Grade > 0 && !failing[s.Grade] {
+			passCount++
+		}
+	}
+
+	studentCount := float64(len(students))
+	meanPoints := sumPoints / studentCount
+
+	variance := 0.0
+	for _, p := range points {
+		variance += (p - meanPoints) * (p - meanPoints)
+	}
+	variance /= studentCount
+
+	stats.Median = median(points)
+	stats.StdDev = math.Sqrt(variance)
+
+	if weightTotal > 0 {
+		stats.WeightedAverage = roundTo2(weightedSum / weightTotal)
+	}
+
+	for grade, count := range stats.GradeCounts {
+		stats.GradePercentages[grade] = roundTo2(float64(count) / studentCount * 100)
+	}
+
+	stats.PassRate = roundTo2(float64(passCount) / studentCount * 100)
+	stats.FailRate = roundTo2(100 - stats.PassRate)
+
+	return stats
+}
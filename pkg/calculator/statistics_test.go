@@ -0,0 +1,126 @@
+package calculator
+
+import (
+	"testing"
+
+	"github.com/payback159/notenschluessel/pkg/models"
+)
+
+func TestClassStatistics_EmptyClass(t *testing.T) {
+	stats := ClassStatistics(nil, nil)
+
+	if stats.Median != 0 || stats.StdDev != 0 || stats.WeightedAverage != 0 {
+		t.Errorf("expected zero-value stats for an empty class, got %+v", stats)
+	}
+}
+
+func TestClassStatistics_MedianAndStdDev(t *testing.T) {
+	students := []models.Student{
+		{Name: "A", Points: 10, Grade: 1},
+		{Name: "B", Points: 20, Grade: 2},
+		{Name: "C", Points: 30, Grade: 3},
+	}
+
+	stats := ClassStatistics(students, nil)
+
+	if stats.Median != 20 {
+		t.Errorf("Median = %.2f, want 20", stats.Median)
+	}
+	if stats.MinPoints != 10 || stats.MaxPoints != 30 {
+		t.Errorf("MinPoints/MaxPoints = %.2f/%.2f, want 10/30", stats.MinPoints, stats.MaxPoints)
+	}
+	wantStdDev := 8.16 // population stddev of 10,20,30
+	if diff := stats.StdDev - wantStdDev; diff > 0.01 || diff < -0.01 {
+		t.Errorf("StdDev = %.2f, want ~%.2f", stats.StdDev, wantStdDev)
+	}
+}
+
+func TestClassStatistics_GradeDistribution(t *testing.T) {
+	students := []models.Student{
+		{Name: "A", Grade: 1},
+		{Name: "B", Grade: 1},
+		{Name: "C", Grade: 5},
+		{Name: "D", Grade: 5},
+	}
+
+	stats := ClassStatistics(students, nil)
+
+	if stats.GradeCounts[1] != 2 || stats.GradeCounts[5] != 2 {
+		t.Errorf("GradeCounts = %v, want 1:2 5:2", stats.GradeCounts)
+	}
+	if stats.GradePercentages[1] != 50 || stats.GradePercentages[5] != 50 {
+		t.Errorf("GradePercentages = %v, want 1:50 5:50", stats.GradePercentages)
+	}
+	if stats.PassRate != 50 || stats.FailRate != 50 {
+		t.Errorf("PassRate/FailRate = %.2f/%.2f, want 50/50", stats.PassRate, stats.FailRate)
+	}
+}
+
+func TestClassStatistics_PassFailRespectsSchemeTierCount(t *testing.T) {
+	// LinearNPoints with n=3 has no grade 5 - its worst tier is 3, so a
+	// hardcoded "grade <= 4 passes" would wrongly call every student here a
+	// pass even though most got the worst available grade.
+	bounds := LinearNPoints{}.ComputeBounds(100, 1, map[string]float64{"n": 3})
+	students := []models.Student{
+		{Name: "A", Grade: 1},
+		{Name: "B", Grade: 3},
+		{Name: "C", Grade: 3},
+	}
+
+	stats := ClassStatistics(students, bounds)
+
+	wantPassRate := roundTo2(1.0 / 3 * 100)
+	if stats.PassRate != wantPassRate {
+		t.Errorf("PassRate = %.2f, want %.2f (only grade 1 of 3 passes)", stats.PassRate, wantPassRate)
+	}
+}
+
+func TestClassStatistics_PassFailUnderSchoolScale6(t *testing.T) {
+	// SchoolScale6 fails both grade 5 and grade 6, unlike SchoolScale5 where
+	// grade 5 is the only failing tier - a hardcoded "only the worst grade
+	// fails" check would wrongly count the grade-5 student as a pass.
+	bounds := SchoolScale6{}.ComputeBounds(100, 1, nil)
+	students := []models.Student{
+		{Name: "A", Grade: 1},
+		{Name: "B", Grade: 4},
+		{Name: "C", Grade: 5},
+		{Name: "D", Grade: 6},
+	}
+
+	stats := ClassStatistics(students, bounds)
+
+	wantPassRate := roundTo2(2.0 / 4 * 100)
+	if stats.PassRate != wantPassRate {
+		t.Errorf("PassRate = %.2f, want %.2f (grades 5 and 6 both fail)", stats.PassRate, wantPassRate)
+	}
+	if stats.FailRate != roundTo2(100-wantPassRate) {
+		t.Errorf("FailRate = %.2f, want %.2f", stats.FailRate, roundTo2(100-wantPassRate))
+	}
+}
+
+func TestClassStatistics_WeightedAverageUsesCredits(t *testing.T) {
+	students := []models.Student{
+		{Name: "A", Grade: 1, Credits: 3},
+		{Name: "B", Grade: 5, Credits: 1},
+	}
+
+	stats := ClassStatistics(students, nil)
+
+	want := (1.0*3 + 5.0*1) / 4
+	if stats.WeightedAverage != roundTo2(want) {
+		t.Errorf("WeightedAverage = %.2f, want %.2f", stats.WeightedAverage, want)
+	}
+}
+
+func TestClassStatistics_UnweightedStudentsCountAsOne(t *testing.T) {
+	students := []models.Student{
+		{Name: "A", Grade: 2},
+		{Name: "B", Grade: 4},
+	}
+
+	stats := ClassStatistics(students, nil)
+
+	if stats.WeightedAverage != 3 {
+		t.Errorf("WeightedAverage = %.2f, want 3 (plain average when Credits is unset)", stats.WeightedAverage)
+	}
+}
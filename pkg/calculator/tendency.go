@@ -0,0 +1,49 @@
+package calculator
+
+import "github.com/payback159/notenschluessel/pkg/models"
+
+// TendencyConfig tunes CalculateTendencyBounds the same way breakPointPercent
+// tunes CalculateGradeBounds.
+type TendencyConfig struct {
+	BreakPointPercent float64
+}
+
+// tendencyLevels are the three tendency bands within each main grade,
+// best points first.
+var tendencyLevels = []string{"+", "", "-"}
+
+// CalculateTendencyBounds subdivides the five main school grades into three
+// tendency bands each (+, "", -) — the fifteen-level "Tendenzen" scheme used
+// in IHK/Oberstufe point-based exams, so a "1+" and a "1-" can be told apart
+// instead of both collapsing to grade 1.
+func CalculateTendencyBounds(maxPoints int, minPoints float64, config TendencyConfig) []models.GradeBound {
+	breakPointPercent := config.BreakPointPercent
+	if breakPointPercent <= 0 {
+		breakPointPercent = 50
+	}
+
+	mainBounds := CalculateGradeBounds(maxPoints, minPoints, breakPointPercent)
+
+	bounds := make([]models.GradeBound, 0, len(mainBounds)*len(tendencyLevels))
+	for _, mb := range mainBounds {
+		band := (mb.UpperBound - mb.LowerBound + minPoints) / float64(len(tendencyLevels))
+
+		for i, tendency := range tendencyLevels {
+			upper := roundToStep(mb.UpperBound-float64(i)*band, minPoints)
+			lower := roundToStep(mb.UpperBound-float64(i+1)*band+minPoints, minPoints)
+			if i == len(tendencyLevels)-1 {
+				lower = mb.LowerBound
+			}
+
+			bounds = append(bounds, models.GradeBound{
+				Grade:      mb.Grade,
+				Tendency:   tendency,
+				LowerBound: lower,
+				UpperBound: upper,
+				Failing:    mb.Failing,
+			})
+		}
+	}
+
+	return bounds
+}
@@ -0,0 +1,60 @@
+package calculator
+
+import (
+	"testing"
+
+	"github.com/payback159/notenschluessel/pkg/models"
+)
+
+func TestCalculateTendencyBounds_FifteenLevels(t *testing.T) {
+	bounds := CalculateTendencyBounds(100, 0.5, TendencyConfig{BreakPointPercent: 50})
+
+	if len(bounds) != 15 {
+		t.Fatalf("expected 15 tendency bounds, got %d", len(bounds))
+	}
+
+	wantTendencies := []string{"+", "", "-"}
+	for i, b := range bounds {
+		wantGrade := i/3 + 1
+		if b.Grade != wantGrade {
+			t.Errorf("bound[%d].Grade = %d, want %d", i, b.Grade, wantGrade)
+		}
+		if b.Tendency != wantTendencies[i%3] {
+			t.Errorf("bound[%d].Tendency = %q, want %q", i, b.Tendency, wantTendencies[i%3])
+		}
+	}
+}
+
+func TestCalculateTendencyBounds_MonotonicAndNonNegative(t *testing.T) {
+	bounds := CalculateTendencyBounds(100, 0.5, TendencyConfig{BreakPointPercent: 50})
+
+	if bounds[0].UpperBound != 100 {
+		t.Errorf("first bound upper = %.2f, want 100", bounds[0].UpperBound)
+	}
+	if bounds[len(bounds)-1].LowerBound != 0 {
+		t.Errorf("last bound lower = %.2f, want 0", bounds[len(bounds)-1].LowerBound)
+	}
+
+	for _, b := range bounds {
+		if b.LowerBound < 0 {
+			t.Errorf("grade %d%s has negative lower bound %.2f", b.Grade, b.Tendency, b.LowerBound)
+		}
+	}
+	for i := 1; i < len(bounds); i++ {
+		if bounds[i].LowerBound > bounds[i-1].LowerBound {
+			t.Errorf("bound[%d] lower bound (%.2f) > bound[%d] lower bound (%.2f)",
+				i, bounds[i].LowerBound, i-1, bounds[i-1].LowerBound)
+		}
+	}
+}
+
+func TestAssignGrades_AssignsTendency(t *testing.T) {
+	bounds := CalculateTendencyBounds(100, 0.5, TendencyConfig{BreakPointPercent: 50})
+	students := []models.Student{{Name: "Alice", Points: 99}}
+
+	result := AssignGrades(students, bounds)
+
+	if result[0].Grade != 1 || result[0].Tendency != "+" {
+		t.Errorf("Alice (99 pts) should be 1+, got grade %d tendency %q", result[0].Grade, result[0].Tendency)
+	}
+}
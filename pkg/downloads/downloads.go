@@ -2,8 +2,10 @@ package downloads
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,17 +15,126 @@ import (
 	"github.com/xuri/excelize/v2"
 )
 
+// utf8BOM is prepended to every CSV response so Excel reliably detects
+// UTF-8 instead of falling back to the system codepage.
+const utf8BOM = "\xEF\xBB\xBF"
+
+// csvDialect controls the field separator and decimal format used when
+// rendering CSV output, so German/Austrian Excel opens the file correctly
+// instead of treating the whole row as a single cell.
+type csvDialect struct {
+	delimiter    string
+	decimalComma bool
+}
+
+var dialectDefault = csvDialect{delimiter: ",", decimalComma: false}
+var dialectExcelDE = csvDialect{delimiter: ";", decimalComma: true}
+
+// dialectTSV separates fields with a tab, so the German locale's comma
+// decimal separator never collides with the field delimiter the way it
+// would in a comma- or semicolon-separated file.
+var dialectTSV = csvDialect{delimiter: "\t", decimalComma: true}
+
+// resolveDialect picks the CSV dialect from an explicit ?dialect= query
+// parameter, falling back to Accept-Language sniffing for German/Austrian
+// clients, and finally the international default.
+func resolveDialect(r *http.Request) csvDialect {
+	switch r.URL.Query().Get("dialect") {
+	case "excel-de":
+		return dialectExcelDE
+	case "default", "international":
+		return dialectDefault
+	}
+
+	if lang := strings.ToLower(r.Header.Get("Accept-Language")); strings.HasPrefix(lang, "de") {
+		return dialectExcelDE
+	}
+
+	return dialectDefault
+}
+
+// formatFloat renders a float with the given precision, honoring the
+// dialect's decimal separator.
+func formatFloat(v float64, precision int, dialect csvDialect) string {
+	s := strconv.FormatFloat(v, 'f', precision, 64)
+	if dialect.decimalComma {
+		s = strings.Replace(s, ".", ",", 1)
+	}
+	return s
+}
+
+// sanitizeCSVField guards a CSV cell against spreadsheet formula injection
+// (leading =, +, -, @, tab or CR) and quotes it per RFC 4180 whenever it
+// contains a comma, a semicolon, a quote or a newline. Tabs do not trigger
+// quoting, so a tab inside a TSV field is written as-is.
+func sanitizeCSVField(field string) string {
+	if field == "" {
+		return field
+	}
+
+	switch field[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		field = "'" + field
+	}
+
+	if strings.ContainsAny(field, ",;\"\n") {
+		field = `"` + strings.ReplaceAll(field, `"`, `""`) + `"`
+	}
+
+	return field
+}
+
+// writeCSVHeader writes the UTF-8 BOM and, for the German dialect, the
+// "sep=;" hint line Excel uses to pick the delimiter without a locale
+// prompt.
+func writeCSVHeader(buffer *bytes.Buffer, dialect csvDialect) {
+	buffer.WriteString(utf8BOM)
+	if dialect.delimiter == dialectExcelDE.delimiter {
+		buffer.WriteString("sep=;\r\n")
+	}
+}
+
+// getSessionIDFromCookie reads the session_id cookie set by
+// handlers.HandleCalculation.
+func getSessionIDFromCookie(r *http.Request) string {
+	cookie, err := r.Cookie("session_id")
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// setDownloadHeaders sets the Content-Type, Content-Disposition and
+// no-cache headers shared by every download handler.
+func setDownloadHeaders(w http.ResponseWriter, contentType, filename string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+}
+
 // HandleGradeScaleCSV handles CSV download of grade scale
-func HandleGradeScaleCSV(w http.ResponseWriter, r *http.Request, sessionStore *session.Store) {
+func HandleGradeScaleCSV(w http.ResponseWriter, r *http.Request, sessionStore session.Store) {
 	start := time.Now()
-	sessionID := r.URL.Query().Get("id")
+	sessionID := getSessionIDFromCookie(r)
 	ip := security.GetClientIP(r)
+	dialect := resolveDialect(r)
+	theme := ResolveThemeFromRequest(r)
+	localizer := ResolveLocalizerFromRequest(r)
 
 	logging.LogInfo("Grade scale CSV download requested",
 		"session_id", sessionID,
 		"ip", ip)
 
-	data, exists := sessionStore.Get(sessionID)
+	data, exists, err := sessionStore.GetContext(r.Context(), sessionID)
+	if err != nil {
+		logging.LogWarn("Session lookup aborted",
+			"session_id", sessionID,
+			"ip", ip,
+			"error", err.Error())
+		http.Error(w, "Keine Daten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
 	if !exists || !data.HasResults {
 		logging.LogWarn("Grade scale download requested but no data available",
 			"session_id", sessionID,
@@ -34,40 +145,45 @@ func HandleGradeScaleCSV(w http.ResponseWriter, r *http.Request, sessionStore *s
 		return
 	}
 
-	// Generate CSV content
 	var buffer bytes.Buffer
-	buffer.WriteString("Note,Punktebereich von,Punktebereich bis\n")
-
-	for _, bound := range data.GradeBounds {
-		line := fmt.Sprintf("%d,%.1f,%.1f\n", bound.Grade, bound.LowerBound, bound.UpperBound)
-		buffer.WriteString(line)
+	if err := NewExport(data).Dialect(dialect).Theme(theme).Localize(localizer).WithGradeScale().Format(FormatCSV).Write(&buffer); err != nil {
+		logging.LogError("Failed to render grade scale CSV", err, "session_id", sessionID, "ip", ip)
+		http.Error(w, "Fehler beim Erstellen der CSV-Datei", http.StatusInternalServerError)
+		return
 	}
 
-	// Set headers for file download
-	w.Header().Set("Content-Type", "text/csv")
-	w.Header().Set("Content-Disposition", "attachment; filename=notenschluessel.csv")
-
-	// Write content to response
-	w.Write(buffer.Bytes())
+	setDownloadHeaders(w, "text/csv", "notenschluessel.csv")
+	status := ServeContentWithRange(w, r, "notenschluessel.csv", buffer.Bytes())
 
 	duration := time.Since(start)
-	logging.LogFileOperation("csv_download", "notenschluessel.csv", int64(buffer.Len()), duration, true,
+	logging.LogFileOperation("csv_download", "notenschluessel.csv", int64(buffer.Len()), duration, status < 400,
 		"session_id", sessionID,
 		"ip", ip,
 		"grade_count", len(data.GradeBounds))
 }
 
 // HandleStudentResultsCSV handles CSV download of student results
-func HandleStudentResultsCSV(w http.ResponseWriter, r *http.Request, sessionStore *session.Store) {
+func HandleStudentResultsCSV(w http.ResponseWriter, r *http.Request, sessionStore session.Store) {
 	start := time.Now()
-	sessionID := r.URL.Query().Get("id")
+	sessionID := getSessionIDFromCookie(r)
 	ip := security.GetClientIP(r)
+	dialect := resolveDialect(r)
+	theme := ResolveThemeFromRequest(r)
+	localizer := ResolveLocalizerFromRequest(r)
 
 	logging.LogInfo("Student results CSV download requested",
 		"session_id", sessionID,
 		"ip", ip)
 
-	data, exists := sessionStore.Get(sessionID)
+	data, exists, err := sessionStore.GetContext(r.Context(), sessionID)
+	if err != nil {
+		logging.LogWarn("Session lookup aborted",
+			"session_id", sessionID,
+			"ip", ip,
+			"error", err.Error())
+		http.Error(w, "Keine Daten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
 	if !exists || !data.HasStudents {
 		logging.LogWarn("Student results download requested but no data available",
 			"session_id", sessionID,
@@ -78,48 +194,45 @@ func HandleStudentResultsCSV(w http.ResponseWriter, r *http.Request, sessionStor
 		return
 	}
 
-	// Generate CSV content
 	var buffer bytes.Buffer
-	buffer.WriteString("Name,Punkte,Note\n")
-
-	for _, student := range data.Students {
-		// Escape names that might contain commas
-		escapedName := student.Name
-		if strings.Contains(escapedName, ",") {
-			escapedName = fmt.Sprintf("\"%s\"", escapedName)
-		}
-		line := fmt.Sprintf("%s,%.1f,%d\n", escapedName, student.Points, student.Grade)
-		buffer.WriteString(line)
+	if err := NewExport(data).Dialect(dialect).Theme(theme).Localize(localizer).WithStudents().Format(FormatCSV).Write(&buffer); err != nil {
+		logging.LogError("Failed to render student results CSV", err, "session_id", sessionID, "ip", ip)
+		http.Error(w, "Fehler beim Erstellen der CSV-Datei", http.StatusInternalServerError)
+		return
 	}
 
-	// Add average at the bottom
-	buffer.WriteString(fmt.Sprintf("Durchschnitt,,%.2f\n", data.AverageGrade))
-
-	// Set headers for file download
-	w.Header().Set("Content-Type", "text/csv")
-	w.Header().Set("Content-Disposition", "attachment; filename=schueler_ergebnisse.csv")
-
-	// Write content to response
-	w.Write(buffer.Bytes())
+	setDownloadHeaders(w, "text/csv", "schueler_ergebnisse.csv")
+	status := ServeContentWithRange(w, r, "schueler_ergebnisse.csv", buffer.Bytes())
 
 	duration := time.Since(start)
-	logging.LogFileOperation("csv_download", "schueler_ergebnisse.csv", int64(buffer.Len()), duration, true,
+	logging.LogFileOperation("csv_download", "schueler_ergebnisse.csv", int64(buffer.Len()), duration, status < 400,
 		"session_id", sessionID,
 		"ip", ip,
 		"student_count", len(data.Students))
 }
 
 // HandleCombinedCSV handles CSV download of combined results
-func HandleCombinedCSV(w http.ResponseWriter, r *http.Request, sessionStore *session.Store) {
+func HandleCombinedCSV(w http.ResponseWriter, r *http.Request, sessionStore session.Store) {
 	start := time.Now()
-	sessionID := r.URL.Query().Get("id")
+	sessionID := getSessionIDFromCookie(r)
 	ip := security.GetClientIP(r)
+	dialect := resolveDialect(r)
+	theme := ResolveThemeFromRequest(r)
+	localizer := ResolveLocalizerFromRequest(r)
 
 	logging.LogInfo("Combined CSV download requested",
 		"session_id", sessionID,
 		"ip", ip)
 
-	data, exists := sessionStore.Get(sessionID)
+	data, exists, err := sessionStore.GetContext(r.Context(), sessionID)
+	if err != nil {
+		logging.LogWarn("Session lookup aborted",
+			"session_id", sessionID,
+			"ip", ip,
+			"error", err.Error())
+		http.Error(w, "Keine Daten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
 	if !exists || !data.HasResults {
 		logging.LogWarn("Combined download requested but no data available",
 			"session_id", sessionID,
@@ -130,62 +243,375 @@ func HandleCombinedCSV(w http.ResponseWriter, r *http.Request, sessionStore *ses
 		return
 	}
 
-	// Generate CSV content
+	export := NewExport(data).Dialect(dialect).Theme(theme).Localize(localizer).WithGradeScale()
+	if data.HasStudents {
+		export = export.WithStudents()
+	}
+
 	var buffer bytes.Buffer
+	if err := export.Format(FormatCSV).Write(&buffer); err != nil {
+		logging.LogError("Failed to render combined CSV", err, "session_id", sessionID, "ip", ip)
+		http.Error(w, "Fehler beim Erstellen der CSV-Datei", http.StatusInternalServerError)
+		return
+	}
 
-	// Grade scale section
-	buffer.WriteString("NOTENSCHLÜSSEL\n")
-	buffer.WriteString("Note,Punktebereich von,Punktebereich bis\n")
-	for _, bound := range data.GradeBounds {
-		line := fmt.Sprintf("%d,%.1f,%.1f\n", bound.Grade, bound.LowerBound, bound.UpperBound)
-		buffer.WriteString(line)
+	setDownloadHeaders(w, "text/csv", "notenschluessel_komplett.csv")
+	status := ServeContentWithRange(w, r, "notenschluessel_komplett.csv", buffer.Bytes())
+
+	duration := time.Since(start)
+	logging.LogFileOperation("csv_download", "notenschluessel_komplett.csv", int64(buffer.Len()), duration, status < 400,
+		"session_id", sessionID,
+		"ip", ip,
+		"has_students", data.HasStudents,
+		"grade_count", len(data.GradeBounds),
+		"student_count", len(data.Students))
+}
+
+// downloadContentTypes maps a "type" query value to the base filename used
+// for every format of that content; the ExportBuilder sections each type
+// selects are chosen in serveDownload. Used by HandleDownload so adding a
+// new output format never needs a new route.
+var downloadContentTypes = map[string]string{
+	"grade-scale":     "notenschluessel",
+	"student-results": "schueler_ergebnisse",
+	"combined":        "notenschluessel_komplett",
+}
+
+// downloadFormats maps a "format" query value to its Content-Type, file
+// extension and ExportBuilder Format.
+var downloadFormats = map[string]struct {
+	contentType string
+	ext         string
+	format      Format
+}{
+	"csv":  {"text/csv", "csv", FormatCSV},
+	"tsv":  {"text/tab-separated-values", "tsv", FormatTSV},
+	"xlsx": {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx", FormatXLSX},
+	"ods":  {odsMimetype, "ods", FormatODS},
+	"pdf":  {"application/pdf", "pdf", FormatPDF},
+	"json": {"application/json", "json", FormatJSON},
+}
+
+// HandleDownload is the unified "/download?type=...&format=..." endpoint:
+// it selects the exported content and output format from query parameters
+// instead of a dedicated path per combination, so new formats (like ods and
+// tsv) don't need new routes of their own. The older per-format paths
+// above are kept for backwards compatibility with existing bookmarks/links.
+func HandleDownload(w http.ResponseWriter, r *http.Request, sessionStore session.Store) {
+	contentType := r.URL.Query().Get("type")
+	if contentType == "" {
+		contentType = "grade-scale"
 	}
 
-	// Empty line separator
-	buffer.WriteString("\n")
+	formatParam := r.URL.Query().Get("format")
+	if formatParam == "" {
+		formatParam = "csv"
+	}
 
-	// Student results section (if available)
-	if data.HasStudents {
-		buffer.WriteString("SCHÜLERERGEBNISSE\n")
-		buffer.WriteString("Name,Punkte,Note\n")
-		for _, student := range data.Students {
-			escapedName := student.Name
-			if strings.Contains(escapedName, ",") {
-				escapedName = fmt.Sprintf("\"%s\"", escapedName)
+	serveDownload(w, r, sessionStore, contentType, formatParam)
+}
+
+// HandleDownloadByView is the content-negotiated "/download/view/{view}"
+// endpoint: the view comes from the path, and the format comes from an
+// explicit "?format=" override or, failing that, the request's Accept
+// header, so API clients can content-negotiate instead of having to know
+// the query-parameter dialect of HandleDownload.
+func HandleDownloadByView(w http.ResponseWriter, r *http.Request, sessionStore session.Store) {
+	contentType := r.PathValue("view")
+
+	formatParam := r.URL.Query().Get("format")
+	if formatParam == "" {
+		formatParam = resolveFormatFromAccept(r, "csv")
+	}
+
+	serveDownload(w, r, sessionStore, contentType, formatParam)
+}
+
+// resolveFormatFromAccept matches the request's Accept header against the
+// content types registered in downloadFormats, honoring q-values so a
+// client's preferred type wins even if it's listed after a lower-priority
+// fallback, and returning fallback if the header is absent, empty, or
+// matches none of them. "*/*" entries are ignored, so a header listing only
+// wildcards also gets fallback.
+func resolveFormatFromAccept(r *http.Request, fallback string) string {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return fallback
+	}
+
+	type candidate struct {
+		key string
+		q   float64
+	}
+	var candidates []candidate
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, q := parseAcceptEntry(part)
+		if mediaType == "" || mediaType == "*/*" {
+			continue
+		}
+		for key, f := range downloadFormats {
+			if f.contentType == mediaType {
+				candidates = append(candidates, candidate{key, q})
 			}
-			line := fmt.Sprintf("%s,%.1f,%d\n", escapedName, student.Points, student.Grade)
-			buffer.WriteString(line)
 		}
-		buffer.WriteString(fmt.Sprintf("Durchschnitt,,%.2f\n", data.AverageGrade))
 	}
 
-	// Set headers for file download
-	w.Header().Set("Content-Type", "text/csv")
-	w.Header().Set("Content-Disposition", "attachment; filename=notenschluessel_komplett.csv")
+	if len(candidates) == 0 {
+		return fallback
+	}
 
-	// Write content to response
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if c.q > best.q {
+			best = c
+		}
+	}
+	return best.key
+}
+
+// parseAcceptEntry splits one comma-separated Accept header entry into its
+// media type and quality value, defaulting to q=1 when none is given or it
+// fails to parse.
+func parseAcceptEntry(entry string) (mediaType string, q float64) {
+	q = 1
+	fields := strings.Split(entry, ";")
+	mediaType = strings.TrimSpace(fields[0])
+	for _, param := range fields[1:] {
+		name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || strings.TrimSpace(name) != "q" {
+			continue
+		}
+		if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			q = parsed
+		}
+	}
+	return mediaType, q
+}
+
+// serveDownload is the shared core of HandleDownload and
+// HandleDownloadByView: given an already-resolved content type and format
+// key, it looks up the session, builds the export and writes the
+// response.
+func serveDownload(w http.ResponseWriter, r *http.Request, sessionStore session.Store, contentType, formatParam string) {
+	start := time.Now()
+	sessionID := getSessionIDFromCookie(r)
+	ip := security.GetClientIP(r)
+
+	filenameBase, ok := downloadContentTypes[contentType]
+	if !ok {
+		http.Error(w, "Unbekannter Exporttyp", http.StatusBadRequest)
+		return
+	}
+
+	outFormat, ok := downloadFormats[formatParam]
+	if !ok {
+		http.Error(w, "Unbekanntes Exportformat", http.StatusBadRequest)
+		return
+	}
+
+	logging.LogInfo("Unified download requested",
+		"session_id", sessionID,
+		"ip", ip,
+		"type", contentType,
+		"format", formatParam)
+
+	data, exists, err := sessionStore.GetContext(r.Context(), sessionID)
+	if err != nil {
+		logging.LogWarn("Session lookup aborted",
+			"session_id", sessionID,
+			"ip", ip,
+			"error", err.Error())
+		http.Error(w, "Keine Daten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
+	if !exists || !data.HasResults {
+		logging.LogWarn("Unified download requested but no data available",
+			"session_id", sessionID,
+			"ip", ip,
+			"type", contentType)
+		http.Error(w, "Keine Daten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
+	if contentType == "student-results" && !data.HasStudents {
+		logging.LogWarn("Unified download requested student results but none are available",
+			"session_id", sessionID,
+			"ip", ip)
+		http.Error(w, "Keine Schülerdaten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
+
+	export := NewExport(data).Theme(ResolveThemeFromRequest(r)).Localize(ResolveLocalizerFromRequest(r))
+	switch contentType {
+	case "student-results":
+		export = export.WithStudents()
+	case "combined":
+		export = export.WithGradeScale()
+		if data.HasStudents {
+			export = export.WithStudents()
+		}
+	default:
+		export = export.WithGradeScale()
+	}
+
+	dialect := dialectDefault
+	switch formatParam {
+	case "tsv":
+		dialect = dialectTSV
+	case "csv":
+		dialect = resolveDialect(r)
+	}
+
+	var buffer bytes.Buffer
+	if err := export.Dialect(dialect).Format(outFormat.format).Write(&buffer); err != nil {
+		logging.LogError("Failed to render export", err, "session_id", sessionID, "ip", ip, "type", contentType, "format", formatParam)
+		http.Error(w, "Fehler beim Erstellen der Datei", http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("%s.%s", filenameBase, outFormat.ext)
+	setDownloadHeaders(w, outFormat.contentType, filename)
 	w.Write(buffer.Bytes())
 
 	duration := time.Since(start)
-	logging.LogFileOperation("csv_download", "notenschluessel_komplett.csv", int64(buffer.Len()), duration, true,
+	logging.LogFileOperation(formatParam+"_download", filename, int64(buffer.Len()), duration, true,
+		"session_id", sessionID,
+		"ip", ip,
+		"type", contentType)
+}
+
+// HandleStudentsJSON handles JSON download of student results, mirroring
+// the CSV/Excel handlers above but as a single JSON array for consumers
+// that will re-import the data via uploads.HandleStudentsBatchImport.
+func HandleStudentsJSON(w http.ResponseWriter, r *http.Request, sessionStore session.Store) {
+	start := time.Now()
+	sessionID := getSessionIDFromCookie(r)
+	ip := security.GetClientIP(r)
+
+	logging.LogInfo("Student JSON download requested",
+		"session_id", sessionID,
+		"ip", ip)
+
+	data, exists, err := sessionStore.GetContext(r.Context(), sessionID)
+	if err != nil {
+		logging.LogWarn("Session lookup aborted",
+			"session_id", sessionID,
+			"ip", ip,
+			"error", err.Error())
+		http.Error(w, "Keine Schülerdaten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
+	if !exists || !data.HasStudents {
+		logging.LogWarn("Student JSON download requested but no data available",
+			"session_id", sessionID,
+			"ip", ip,
+			"session_exists", exists)
+		http.Error(w, "Keine Schülerdaten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
+
+	setDownloadHeaders(w, "application/json", "students.json")
+
+	cw := &countingWriter{w: w}
+	if err := json.NewEncoder(cw).Encode(data.Students); err != nil {
+		logging.LogError("Failed to write students JSON", err, "session_id", sessionID, "ip", ip)
+		return
+	}
+
+	duration := time.Since(start)
+	logging.LogFileOperation("json_download", "students.json", cw.bytes, duration, true,
 		"session_id", sessionID,
 		"ip", ip,
-		"has_students", data.HasStudents,
-		"grade_count", len(data.GradeBounds),
 		"student_count", len(data.Students))
 }
 
+// HandleStudentsNDJSON handles newline-delimited JSON download of student
+// results, for streaming large classes without buffering a JSON array.
+func HandleStudentsNDJSON(w http.ResponseWriter, r *http.Request, sessionStore session.Store) {
+	start := time.Now()
+	sessionID := getSessionIDFromCookie(r)
+	ip := security.GetClientIP(r)
+
+	logging.LogInfo("Student NDJSON download requested",
+		"session_id", sessionID,
+		"ip", ip)
+
+	data, exists, err := sessionStore.GetContext(r.Context(), sessionID)
+	if err != nil {
+		logging.LogWarn("Session lookup aborted",
+			"session_id", sessionID,
+			"ip", ip,
+			"error", err.Error())
+		http.Error(w, "Keine Schülerdaten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
+	if !exists || !data.HasStudents {
+		logging.LogWarn("Student NDJSON download requested but no data available",
+			"session_id", sessionID,
+			"ip", ip,
+			"session_exists", exists)
+		http.Error(w, "Keine Schülerdaten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
+
+	setDownloadHeaders(w, "application/x-ndjson", "students.ndjson")
+
+	cw := &countingWriter{w: w}
+	encoder := json.NewEncoder(cw)
+	for _, student := range data.Students {
+		if err := encoder.Encode(student); err != nil {
+			logging.LogError("Failed to write student NDJSON row", err, "session_id", sessionID, "ip", ip)
+			return
+		}
+	}
+
+	duration := time.Since(start)
+	logging.LogFileOperation("ndjson_download", "students.ndjson", cw.bytes, duration, true,
+		"session_id", sessionID,
+		"ip", ip,
+		"student_count", len(data.Students))
+}
+
+// newGradeFillStyles registers one bordered, color-filled style per grade in
+// colors (the active theme's palette), and returns them keyed by grade so
+// callers can look up the style for a given row without re-registering it
+// per cell.
+func newGradeFillStyles(f *excelize.File, colors map[int]string) map[int]int {
+	styles := make(map[int]int, len(colors))
+	for grade, hex := range colors {
+		style, _ := f.NewStyle(&excelize.Style{
+			Fill: excelize.Fill{Type: "pattern", Color: []string{hex}, Pattern: 1},
+			Border: []excelize.Border{
+				{Type: "left", Color: "#000000", Style: 1},
+				{Type: "top", Color: "#000000", Style: 1},
+				{Type: "right", Color: "#000000", Style: 1},
+				{Type: "bottom", Color: "#000000", Style: 1},
+			},
+		})
+		styles[grade] = style
+	}
+	return styles
+}
+
 // HandleGradeScaleExcel handles Excel download of grade scale
-func HandleGradeScaleExcel(w http.ResponseWriter, r *http.Request, sessionStore *session.Store) {
+func HandleGradeScaleExcel(w http.ResponseWriter, r *http.Request, sessionStore session.Store) {
 	start := time.Now()
-	sessionID := r.URL.Query().Get("id")
+	sessionID := getSessionIDFromCookie(r)
 	ip := security.GetClientIP(r)
 
 	logging.LogInfo("Grade scale Excel download requested",
 		"session_id", sessionID,
 		"ip", ip)
 
-	data, exists := sessionStore.Get(sessionID)
+	data, exists, err := sessionStore.GetContext(r.Context(), sessionID)
+	if err != nil {
+		logging.LogWarn("Session lookup aborted",
+			"session_id", sessionID,
+			"ip", ip,
+			"error", err.Error())
+		http.Error(w, "Keine Daten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
 	if !exists || !data.HasResults {
 		logging.LogWarn("Excel grade scale download requested but no data available",
 			"session_id", sessionID,
@@ -194,122 +620,42 @@ func HandleGradeScaleExcel(w http.ResponseWriter, r *http.Request, sessionStore
 		return
 	}
 
-	// Create Excel file
-	f := excelize.NewFile()
-	defer func() {
-		if err := f.Close(); err != nil {
-			logging.LogError("Failed to close Excel file", err, "session_id", sessionID, "ip", ip)
-		}
-	}()
-
-	sheetName := "Notenschlüssel"
-	f.NewSheet(sheetName)
-	f.DeleteSheet("Sheet1")
-
-	// Add headers
-	f.SetCellValue(sheetName, "A1", "Note")
-	f.SetCellValue(sheetName, "B1", "Punktebereich von")
-	f.SetCellValue(sheetName, "C1", "Punktebereich bis")
-
-	// Define grade styles with colors
-	gradeStyles := make(map[int]int)
-
-	style1, _ := f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{Type: "pattern", Color: []string{"#c6f6d5"}, Pattern: 1},
-		Border: []excelize.Border{
-			{Type: "left", Color: "#000000", Style: 1},
-			{Type: "top", Color: "#000000", Style: 1},
-			{Type: "right", Color: "#000000", Style: 1},
-			{Type: "bottom", Color: "#000000", Style: 1},
-		},
-	})
-	gradeStyles[1] = style1
-
-	style2, _ := f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{Type: "pattern", Color: []string{"#d4edda"}, Pattern: 1},
-		Border: []excelize.Border{
-			{Type: "left", Color: "#000000", Style: 1},
-			{Type: "top", Color: "#000000", Style: 1},
-			{Type: "right", Color: "#000000", Style: 1},
-			{Type: "bottom", Color: "#000000", Style: 1},
-		},
-	})
-	gradeStyles[2] = style2
-
-	style3, _ := f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{Type: "pattern", Color: []string{"#fff3cd"}, Pattern: 1},
-		Border: []excelize.Border{
-			{Type: "left", Color: "#000000", Style: 1},
-			{Type: "top", Color: "#000000", Style: 1},
-			{Type: "right", Color: "#000000", Style: 1},
-			{Type: "bottom", Color: "#000000", Style: 1},
-		},
-	})
-	gradeStyles[3] = style3
-
-	style4, _ := f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{Type: "pattern", Color: []string{"#ffe8cc"}, Pattern: 1},
-		Border: []excelize.Border{
-			{Type: "left", Color: "#000000", Style: 1},
-			{Type: "top", Color: "#000000", Style: 1},
-			{Type: "right", Color: "#000000", Style: 1},
-			{Type: "bottom", Color: "#000000", Style: 1},
-		},
-	})
-	gradeStyles[4] = style4
-
-	style5, _ := f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{Type: "pattern", Color: []string{"#f8d7da"}, Pattern: 1},
-		Border: []excelize.Border{
-			{Type: "left", Color: "#000000", Style: 1},
-			{Type: "top", Color: "#000000", Style: 1},
-			{Type: "right", Color: "#000000", Style: 1},
-			{Type: "bottom", Color: "#000000", Style: 1},
-		},
-	})
-	gradeStyles[5] = style5
-
-	// Add data with styling
-	for i, bound := range data.GradeBounds {
-		row := i + 2
-		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), bound.Grade)
-		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), bound.LowerBound)
-		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), bound.UpperBound)
-
-		if style, exists := gradeStyles[bound.Grade]; exists {
-			f.SetCellStyle(sheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("C%d", row), style)
-		}
-	}
-
-	// Set headers for file download
-	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
-	w.Header().Set("Content-Disposition", "attachment; filename=notenschluessel.xlsx")
-
-	// Write the file to response
-	if err := f.Write(w); err != nil {
+	var buffer bytes.Buffer
+	if err := NewExport(data).Theme(ResolveThemeFromRequest(r)).Localize(ResolveLocalizerFromRequest(r)).WithGradeScale().Format(FormatXLSX).Write(&buffer); err != nil {
 		logging.LogError("Failed to write Excel file", err, "session_id", sessionID, "ip", ip)
 		http.Error(w, "Fehler beim Erstellen der Excel-Datei", http.StatusInternalServerError)
 		return
 	}
 
+	setDownloadHeaders(w, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "notenschluessel.xlsx")
+	status := ServeContentWithRange(w, r, "notenschluessel.xlsx", buffer.Bytes())
+
 	duration := time.Since(start)
-	logging.LogFileOperation("excel_download", "notenschluessel.xlsx", 0, duration, true,
+	logging.LogFileOperation("excel_download", "notenschluessel.xlsx", int64(buffer.Len()), duration, status < 400,
 		"session_id", sessionID,
 		"ip", ip,
 		"grade_count", len(data.GradeBounds))
 }
 
 // HandleStudentResultsExcel handles Excel download of student results
-func HandleStudentResultsExcel(w http.ResponseWriter, r *http.Request, sessionStore *session.Store) {
+func HandleStudentResultsExcel(w http.ResponseWriter, r *http.Request, sessionStore session.Store) {
 	start := time.Now()
-	sessionID := r.URL.Query().Get("id")
+	sessionID := getSessionIDFromCookie(r)
 	ip := security.GetClientIP(r)
 
 	logging.LogInfo("Student results Excel download requested",
 		"session_id", sessionID,
 		"ip", ip)
 
-	data, exists := sessionStore.Get(sessionID)
+	data, exists, err := sessionStore.GetContext(r.Context(), sessionID)
+	if err != nil {
+		logging.LogWarn("Session lookup aborted",
+			"session_id", sessionID,
+			"ip", ip,
+			"error", err.Error())
+		http.Error(w, "Keine Daten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
 	if !exists || !data.HasStudents {
 		logging.LogWarn("Excel student download requested but no data available",
 			"session_id", sessionID,
@@ -318,65 +664,42 @@ func HandleStudentResultsExcel(w http.ResponseWriter, r *http.Request, sessionSt
 		return
 	}
 
-	// Create Excel file
-	f := excelize.NewFile()
-	defer func() {
-		if err := f.Close(); err != nil {
-			logging.LogError("Failed to close Excel file", err, "session_id", sessionID, "ip", ip)
-		}
-	}()
-
-	sheetName := "Schülerergebnisse"
-	f.NewSheet(sheetName)
-	f.DeleteSheet("Sheet1")
-
-	// Add headers
-	f.SetCellValue(sheetName, "A1", "Name")
-	f.SetCellValue(sheetName, "B1", "Punkte")
-	f.SetCellValue(sheetName, "C1", "Note")
-
-	// Add data
-	for i, student := range data.Students {
-		row := i + 2
-		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), student.Name)
-		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), student.Points)
-		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), student.Grade)
-	}
-
-	// Add average at the bottom
-	lastRow := len(data.Students) + 2
-	f.SetCellValue(sheetName, fmt.Sprintf("A%d", lastRow), "Durchschnitt")
-	f.SetCellValue(sheetName, fmt.Sprintf("C%d", lastRow), data.AverageGrade)
-
-	// Set headers for file download
-	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
-	w.Header().Set("Content-Disposition", "attachment; filename=schueler_ergebnisse.xlsx")
-
-	// Write the file to response
-	if err := f.Write(w); err != nil {
+	var buffer bytes.Buffer
+	if err := NewExport(data).Theme(ResolveThemeFromRequest(r)).Localize(ResolveLocalizerFromRequest(r)).WithStudents().Format(FormatXLSX).Write(&buffer); err != nil {
 		logging.LogError("Failed to write Excel file to response", err, "session_id", sessionID, "ip", ip)
 		http.Error(w, "Fehler beim Erstellen der Excel-Datei", http.StatusInternalServerError)
 		return
 	}
 
+	setDownloadHeaders(w, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "schueler_ergebnisse.xlsx")
+	status := ServeContentWithRange(w, r, "schueler_ergebnisse.xlsx", buffer.Bytes())
+
 	duration := time.Since(start)
-	logging.LogFileOperation("excel_download", "schueler_ergebnisse.xlsx", 0, duration, true,
+	logging.LogFileOperation("excel_download", "schueler_ergebnisse.xlsx", int64(buffer.Len()), duration, status < 400,
 		"session_id", sessionID,
 		"ip", ip,
 		"student_count", len(data.Students))
 }
 
 // HandleCombinedExcel handles Excel download of combined results
-func HandleCombinedExcel(w http.ResponseWriter, r *http.Request, sessionStore *session.Store) {
+func HandleCombinedExcel(w http.ResponseWriter, r *http.Request, sessionStore session.Store) {
 	start := time.Now()
-	sessionID := r.URL.Query().Get("id")
+	sessionID := getSessionIDFromCookie(r)
 	ip := security.GetClientIP(r)
 
 	logging.LogInfo("Combined Excel download requested",
 		"session_id", sessionID,
 		"ip", ip)
 
-	data, exists := sessionStore.Get(sessionID)
+	data, exists, err := sessionStore.GetContext(r.Context(), sessionID)
+	if err != nil {
+		logging.LogWarn("Session lookup aborted",
+			"session_id", sessionID,
+			"ip", ip,
+			"error", err.Error())
+		http.Error(w, "Keine Daten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
 	if !exists || !data.HasResults {
 		logging.LogWarn("Excel combined download requested but no data available",
 			"session_id", sessionID,
@@ -385,145 +708,23 @@ func HandleCombinedExcel(w http.ResponseWriter, r *http.Request, sessionStore *s
 		return
 	}
 
-	// Create Excel file
-	f := excelize.NewFile()
-	defer func() {
-		if err := f.Close(); err != nil {
-			logging.LogError("Failed to close Excel file", err, "session_id", sessionID, "ip", ip)
-		}
-	}()
-
-	// Define grade styles
-	gradeStyles := make(map[int]int)
-
-	style1, _ := f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{Type: "pattern", Color: []string{"#c6f6d5"}, Pattern: 1},
-		Border: []excelize.Border{
-			{Type: "left", Color: "#000000", Style: 1},
-			{Type: "top", Color: "#000000", Style: 1},
-			{Type: "right", Color: "#000000", Style: 1},
-			{Type: "bottom", Color: "#000000", Style: 1},
-		},
-	})
-	gradeStyles[1] = style1
-
-	style2, _ := f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{Type: "pattern", Color: []string{"#d4edda"}, Pattern: 1},
-		Border: []excelize.Border{
-			{Type: "left", Color: "#000000", Style: 1},
-			{Type: "top", Color: "#000000", Style: 1},
-			{Type: "right", Color: "#000000", Style: 1},
-			{Type: "bottom", Color: "#000000", Style: 1},
-		},
-	})
-	gradeStyles[2] = style2
-
-	style3, _ := f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{Type: "pattern", Color: []string{"#fff3cd"}, Pattern: 1},
-		Border: []excelize.Border{
-			{Type: "left", Color: "#000000", Style: 1},
-			{Type: "top", Color: "#000000", Style: 1},
-			{Type: "right", Color: "#000000", Style: 1},
-			{Type: "bottom", Color: "#000000", Style: 1},
-		},
-	})
-	gradeStyles[3] = style3
-
-	style4, _ := f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{Type: "pattern", Color: []string{"#ffe8cc"}, Pattern: 1},
-		Border: []excelize.Border{
-			{Type: "left", Color: "#000000", Style: 1},
-			{Type: "top", Color: "#000000", Style: 1},
-			{Type: "right", Color: "#000000", Style: 1},
-			{Type: "bottom", Color: "#000000", Style: 1},
-		},
-	})
-	gradeStyles[4] = style4
-
-	style5, _ := f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{Type: "pattern", Color: []string{"#f8d7da"}, Pattern: 1},
-		Border: []excelize.Border{
-			{Type: "left", Color: "#000000", Style: 1},
-			{Type: "top", Color: "#000000", Style: 1},
-			{Type: "right", Color: "#000000", Style: 1},
-			{Type: "bottom", Color: "#000000", Style: 1},
-		},
-	})
-	gradeStyles[5] = style5
-
-	// Header style
-	headerStyle, _ := f.NewStyle(&excelize.Style{
-		Font: &excelize.Font{Bold: true},
-		Fill: excelize.Fill{Type: "pattern", Color: []string{"#f2f2f2"}, Pattern: 1},
-		Border: []excelize.Border{
-			{Type: "left", Color: "#000000", Style: 1},
-			{Type: "top", Color: "#000000", Style: 1},
-			{Type: "right", Color: "#000000", Style: 1},
-			{Type: "bottom", Color: "#000000", Style: 1},
-		},
-	})
-
-	// Create grade scale sheet
-	gradeSheetName := "Notenschlüssel"
-	f.NewSheet(gradeSheetName)
-	f.DeleteSheet("Sheet1")
-
-	// Add grade scale headers
-	f.SetCellValue(gradeSheetName, "A1", "Note")
-	f.SetCellValue(gradeSheetName, "B1", "Punktebereich von")
-	f.SetCellValue(gradeSheetName, "C1", "Punktebereich bis")
-	f.SetCellStyle(gradeSheetName, "A1", "C1", headerStyle)
-
-	// Add grade scale data
-	for i, bound := range data.GradeBounds {
-		row := i + 2
-		f.SetCellValue(gradeSheetName, fmt.Sprintf("A%d", row), bound.Grade)
-		f.SetCellValue(gradeSheetName, fmt.Sprintf("B%d", row), bound.LowerBound)
-		f.SetCellValue(gradeSheetName, fmt.Sprintf("C%d", row), bound.UpperBound)
-
-		if style, exists := gradeStyles[bound.Grade]; exists {
-			f.SetCellStyle(gradeSheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("C%d", row), style)
-		}
-	}
-
-	// Create student results sheet if students are available
+	export := NewExport(data).Theme(ResolveThemeFromRequest(r)).Localize(ResolveLocalizerFromRequest(r)).WithGradeScale()
 	if data.HasStudents {
-		studentSheetName := "Schülerergebnisse"
-		f.NewSheet(studentSheetName)
-
-		// Add student headers
-		f.SetCellValue(studentSheetName, "A1", "Name")
-		f.SetCellValue(studentSheetName, "B1", "Punkte")
-		f.SetCellValue(studentSheetName, "C1", "Note")
-		f.SetCellStyle(studentSheetName, "A1", "C1", headerStyle)
-
-		// Add student data
-		for i, student := range data.Students {
-			row := i + 2
-			f.SetCellValue(studentSheetName, fmt.Sprintf("A%d", row), student.Name)
-			f.SetCellValue(studentSheetName, fmt.Sprintf("B%d", row), student.Points)
-			f.SetCellValue(studentSheetName, fmt.Sprintf("C%d", row), student.Grade)
-		}
-
-		// Add average
-		lastRow := len(data.Students) + 2
-		f.SetCellValue(studentSheetName, fmt.Sprintf("A%d", lastRow), "Durchschnitt")
-		f.SetCellValue(studentSheetName, fmt.Sprintf("C%d", lastRow), data.AverageGrade)
+		export = export.WithStudents()
 	}
 
-	// Set headers for file download
-	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
-	w.Header().Set("Content-Disposition", "attachment; filename=notenschluessel_komplett.xlsx")
-
-	// Write the file to response
-	if err := f.Write(w); err != nil {
+	var buffer bytes.Buffer
+	if err := export.Format(FormatXLSX).Write(&buffer); err != nil {
 		logging.LogError("Failed to write combined Excel file", err, "session_id", sessionID, "ip", ip)
 		http.Error(w, "Fehler beim Erstellen der Excel-Datei", http.StatusInternalServerError)
 		return
 	}
 
+	setDownloadHeaders(w, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "notenschluessel_komplett.xlsx")
+	status := ServeContentWithRange(w, r, "notenschluessel_komplett.xlsx", buffer.Bytes())
+
 	duration := time.Since(start)
-	logging.LogFileOperation("excel_download", "notenschluessel_komplett.xlsx", 0, duration, true,
+	logging.LogFileOperation("excel_download", "notenschluessel_komplett.xlsx", int64(buffer.Len()), duration, status < 400,
 		"session_id", sessionID,
 		"ip", ip,
 		"has_students", data.HasStudents,
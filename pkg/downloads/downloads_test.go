@@ -3,6 +3,7 @@ package downloads
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/payback159/notenschluessel/pkg/logging"
@@ -120,7 +121,7 @@ func TestSetDownloadHeaders(t *testing.T) {
 
 // --- CSV Download Handlers ---
 
-func newTestStore(sessionID string, data models.PageData) *session.Store {
+func newTestStore(sessionID string, data models.PageData) session.Store {
 	store := session.NewStore()
 	store.Set(sessionID, data)
 	return store
@@ -164,7 +165,7 @@ func TestHandleGradeScaleCSV_WithData(t *testing.T) {
 	if w.Header().Get("Content-Type") != "text/csv" {
 		t.Errorf("Content-Type: got %s", w.Header().Get("Content-Type"))
 	}
-	body := w.Body.String()
+	body := strings.TrimPrefix(w.Body.String(), utf8BOM)
 	if len(body) == 0 {
 		t.Error("response body should not be empty")
 	}
@@ -248,6 +249,77 @@ func TestHandleCombinedCSV_WithData(t *testing.T) {
 	}
 }
 
+// --- JSON/NDJSON handlers ---
+
+func TestHandleStudentsJSON_WithStudents(t *testing.T) {
+	sid := "sid-json-students"
+	data := models.PageData{
+		HasStudents: true,
+		Students: []models.Student{
+			{Name: "Alice", Points: 90, Grade: 1},
+			{Name: "Bob", Points: 60, Grade: 3},
+		},
+	}
+	store := newTestStore(sid, data)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/download/students.json", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+
+	HandleStudentsJSON(w, req, store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type: got %s", ct)
+	}
+	if !strings.Contains(w.Body.String(), `"Alice"`) {
+		t.Error("expected student data in JSON response")
+	}
+}
+
+func TestHandleStudentsJSON_NoStudents(t *testing.T) {
+	sid := "sid-json-empty"
+	store := newTestStore(sid, models.PageData{HasStudents: false})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/download/students.json", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+
+	HandleStudentsJSON(w, req, store)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want 400, got %d", w.Code)
+	}
+}
+
+func TestHandleStudentsNDJSON_WithStudents(t *testing.T) {
+	sid := "sid-ndjson-students"
+	data := models.PageData{
+		HasStudents: true,
+		Students: []models.Student{
+			{Name: "Alice", Points: 90, Grade: 1},
+			{Name: "Bob", Points: 60, Grade: 3},
+		},
+	}
+	store := newTestStore(sid, data)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/download/students.ndjson", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+
+	HandleStudentsNDJSON(w, req, store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", w.Code)
+	}
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Errorf("expected 2 NDJSON lines, got %d", len(lines))
+	}
+}
+
 // --- Excel handlers (basic smoke tests) ---
 
 func TestHandleGradeScaleExcel_NoSession(t *testing.T) {
@@ -0,0 +1,442 @@
+package downloads
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/payback159/notenschluessel/pkg/models"
+	"github.com/xuri/excelize/v2"
+)
+
+// Format selects the output encoding an ExportBuilder renders to.
+type Format int
+
+const (
+	FormatCSV Format = iota
+	FormatTSV
+	FormatXLSX
+	FormatPDF
+	FormatODS
+	FormatJSON
+)
+
+// Row is one exported row, in column order. Cells are plain Go values
+// (string, int, float64, or nil); each Format implementation renders them
+// in whatever way fits that format (dialect-aware text for CSV, native
+// cell types for XLSX).
+type Row []interface{}
+
+// GradeColorFunc returns the grade number that should color a row, or 0 if
+// the row should not be colored. Passed to Style so every format shares
+// the same coloring decision instead of each handler re-implementing it.
+type GradeColorFunc func(row Row) int
+
+// exportSection is one titled block of an export: an Excel sheet, or a
+// titled block within a single CSV/PDF document.
+type exportSection struct {
+	title     string
+	headers   []string
+	rows      []Row
+	colorFunc GradeColorFunc
+}
+
+// ExportBuilder collapses the grade-scale/student-results/combined
+// CSV and Excel handlers into a single fluent pipeline, so the grade
+// color map and row-writing logic live in one place instead of being
+// duplicated per handler and per format.
+type ExportBuilder struct {
+	data         models.PageData
+	dialect      csvDialect
+	theme        Theme
+	localizer    Localizer
+	sections     []exportSection
+	outputFormat *Format
+}
+
+// NewExport starts a builder over the given session data, defaulting to the
+// Austrian 5-tier theme and German headers, matching every export before
+// theming/localization existed.
+func NewExport(data models.PageData) *ExportBuilder {
+	return &ExportBuilder{data: data, dialect: dialectDefault, theme: ThemeAT5, localizer: localeDE}
+}
+
+// Dialect sets the CSV dialect used by Format(FormatCSV) and
+// Format(FormatTSV), which both render through writeCSV; it has no effect on
+// the other formats.
+func (b *ExportBuilder) Dialect(d csvDialect) *ExportBuilder {
+	b.dialect = d
+	return b
+}
+
+// Theme sets the grade-color palette used by every format.
+func (b *ExportBuilder) Theme(t Theme) *ExportBuilder {
+	b.theme = t
+	return b
+}
+
+// Localize sets the language of the sheet/column headers rendered by
+// WithGradeScale/WithStudents.
+func (b *ExportBuilder) Localize(l Localizer) *ExportBuilder {
+	b.localizer = l
+	return b
+}
+
+// Sheet starts a new section (an Excel sheet, or a titled CSV/PDF block).
+func (b *ExportBuilder) Sheet(title string) *ExportBuilder {
+	b.sections = append(b.sections, exportSection{title: title})
+	return b
+}
+
+// current returns the section being built, starting an untitled one if
+// Sheet hasn't been called yet.
+func (b *ExportBuilder) current() *exportSection {
+	if len(b.sections) == 0 {
+		b.Sheet("")
+	}
+	return &b.sections[len(b.sections)-1]
+}
+
+// Headers sets the column headers of the current section.
+func (b *ExportBuilder) Headers(headers ...string) *ExportBuilder {
+	b.current().headers = headers
+	return b
+}
+
+// Rows sets the data rows of the current section.
+func (b *ExportBuilder) Rows(rows []Row) *ExportBuilder {
+	b.current().rows = rows
+	return b
+}
+
+// Style attaches the per-row grade coloring function of the current
+// section.
+func (b *ExportBuilder) Style(fn GradeColorFunc) *ExportBuilder {
+	b.current().colorFunc = fn
+	return b
+}
+
+// hasTendencies reports whether any grade bound in the session data carries
+// a tendency, so the export only grows a tendency column when the active
+// scheme actually uses one (CalculateTendencyBounds), leaving every other
+// export unchanged.
+func (b *ExportBuilder) hasTendencies() bool {
+	for _, bound := range b.data.GradeBounds {
+		if bound.Tendency != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// WithGradeScale appends the grade scale table section, using the grade
+// bounds already passed to NewExport, colored the same way as every other
+// grade-scale export.
+func (b *ExportBuilder) WithGradeScale() *ExportBuilder {
+	tendencies := b.hasTendencies()
+
+	rows := make([]Row, 0, len(b.data.GradeBounds))
+	for _, bound := range b.data.GradeBounds {
+		row := Row{bound.Grade, bound.LowerBound, bound.UpperBound}
+		if tendencies {
+			row = append(row, bound.Tendency)
+		}
+		rows = append(rows, row)
+	}
+
+	headers := []string{b.localizer.GradeHeader(), b.localizer.PointsFromHeader(), b.localizer.PointsToHeader()}
+	if tendencies {
+		headers = append(headers, b.localizer.TendencyHeader())
+	}
+
+	return b.Sheet(b.localizer.GradeScaleTitle()).
+		Headers(headers...).
+		Rows(rows).
+		Style(func(row Row) int {
+			grade, _ := row[0].(int)
+			return grade
+		})
+}
+
+// WithStudents appends the student roster section (name/points/grade plus
+// the trailing average row), using the student data already passed to
+// NewExport, colored per student grade.
+func (b *ExportBuilder) WithStudents() *ExportBuilder {
+	tendencies := b.hasTendencies()
+
+	rows := make([]Row, 0, len(b.data.Students)+1)
+	for _, s := range b.data.Students {
+		row := Row{s.Name, s.Points, s.Grade}
+		if tendencies {
+			row = append(row, s.Tendency)
+		}
+		rows = append(rows, row)
+	}
+	averageRow := Row{b.localizer.AverageLabel(), nil, b.data.AverageGrade}
+	if tendencies {
+		averageRow = append(averageRow, nil)
+	}
+	rows = append(rows, averageRow)
+
+	headers := []string{b.localizer.NameHeader(), b.localizer.PointsHeader(), b.localizer.GradeHeader()}
+	if tendencies {
+		headers = append(headers, b.localizer.TendencyHeader())
+	}
+
+	return b.Sheet(b.localizer.StudentResultsTitle()).
+		Headers(headers...).
+		Rows(rows).
+		Style(func(row Row) int {
+			grade, ok := row[2].(int)
+			if !ok {
+				return 0
+			}
+			return grade
+		})
+}
+
+// Write renders the builder's sections in the selected format.
+func (b *ExportBuilder) Write(w io.Writer) error {
+	switch b.format() {
+	case FormatCSV, FormatTSV:
+		return b.writeCSV(w)
+	case FormatXLSX:
+		return b.writeXLSX(w)
+	case FormatPDF:
+		return b.writePDF(w)
+	case FormatODS:
+		return b.writeODS(w)
+	case FormatJSON:
+		return b.writeJSON(w)
+	default:
+		return fmt.Errorf("export format not yet supported")
+	}
+}
+
+// Format selects the output encoding; it is the last call before Write.
+func (b *ExportBuilder) Format(f Format) *ExportBuilder {
+	b.outputFormat = &f
+	return b
+}
+
+func (b *ExportBuilder) format() Format {
+	if b.outputFormat == nil {
+		return FormatCSV
+	}
+	return *b.outputFormat
+}
+
+// formatCellCSV renders a cell value as CSV text, honoring the dialect's
+// decimal separator and formula-injection guarding.
+func formatCellCSV(v interface{}, dialect csvDialect) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return sanitizeCSVField(t)
+	case int:
+		return strconv.Itoa(t)
+	case float64:
+		return formatFloat(t, 2, dialect)
+	default:
+		return sanitizeCSVField(fmt.Sprintf("%v", t))
+	}
+}
+
+// writeCSV renders every section as a titled CSV block (titles are only
+// written when there is more than one section, matching the historical
+// single-section CSV handlers which had no title line).
+func (b *ExportBuilder) writeCSV(w io.Writer) error {
+	var buf bytes.Buffer
+	writeCSVHeader(&buf, b.dialect)
+
+	for i, sec := range b.sections {
+		if len(b.sections) > 1 && sec.title != "" {
+			buf.WriteString(strings.ToUpper(sec.title) + "\r\n")
+		}
+		buf.WriteString(strings.Join(sec.headers, b.dialect.delimiter) + "\r\n")
+		for _, row := range sec.rows {
+			fields := make([]string, len(row))
+			for j, v := range row {
+				fields[j] = formatCellCSV(v, b.dialect)
+			}
+			buf.WriteString(strings.Join(fields, b.dialect.delimiter) + "\r\n")
+		}
+		if i < len(b.sections)-1 {
+			buf.WriteString("\r\n")
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeXLSX renders every section as its own sheet, streaming rows via
+// excelize's StreamWriter and coloring them via each section's
+// GradeColorFunc.
+func (b *ExportBuilder) writeXLSX(w io.Writer) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	gradeStyles := newGradeFillStyles(f, b.theme.GradeColors)
+
+	for i, sec := range b.sections {
+		sheetName := sec.title
+		if sheetName == "" {
+			sheetName = fmt.Sprintf("Sheet%d", i+1)
+		}
+		f.NewSheet(sheetName)
+		if i == 0 {
+			f.DeleteSheet("Sheet1")
+		}
+
+		sw, err := f.NewStreamWriter(sheetName)
+		if err != nil {
+			return err
+		}
+
+		headerCells := make([]interface{}, len(sec.headers))
+		for j, h := range sec.headers {
+			headerCells[j] = excelize.Cell{StyleID: headerStyle, Value: h}
+		}
+		if err := sw.SetRow("A1", headerCells); err != nil {
+			return err
+		}
+
+		for rowIdx, row := range sec.rows {
+			cellRef, err := excelize.CoordinatesToCellName(1, rowIdx+2)
+			if err != nil {
+				return err
+			}
+			style := 0
+			if sec.colorFunc != nil {
+				if grade := sec.colorFunc(row); grade > 0 {
+					style = gradeStyles[grade]
+				}
+			}
+			values := make([]interface{}, len(row))
+			for j, v := range row {
+				if style > 0 {
+					values[j] = excelize.Cell{StyleID: style, Value: v}
+				} else {
+					values[j] = v
+				}
+			}
+			if err := sw.SetRow(cellRef, values); err != nil {
+				return err
+			}
+		}
+
+		if err := sw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return f.Write(w)
+}
+
+// writePDF renders every section as a bordered, color-coded table using
+// the same grade colors as the Excel/CSV exports. It does not include the
+// richer histogram/statistics blocks of the dedicated PDF handlers in
+// pdf.go, which build their own report layout.
+func (b *ExportBuilder) writePDF(w io.Writer) error {
+	title := "Export"
+	if len(b.sections) > 0 && b.sections[0].title != "" {
+		title = b.sections[0].title
+	}
+	pdf := newReportPDF(title)
+	for _, sec := range b.sections {
+		drawExportSectionTable(pdf, sec, b.theme)
+	}
+	return pdf.Output(w)
+}
+
+// drawExportSectionTable renders one exportSection as an evenly-columned,
+// bordered table, tinting each row via its GradeColorFunc and theme.
+func drawExportSectionTable(pdf *gofpdf.Fpdf, sec exportSection, theme Theme) {
+	if sec.title != "" {
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(0, 7, sec.title, "", 1, "L", false, 0, "")
+	}
+	if len(sec.headers) == 0 {
+		return
+	}
+	colWidth := 180.0 / float64(len(sec.headers))
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(0xf2, 0xf2, 0xf2)
+	for i, h := range sec.headers {
+		ln := 0
+		if i == len(sec.headers)-1 {
+			ln = 1
+		}
+		pdf.CellFormat(colWidth, 8, h, "1", ln, "C", true, 0, "")
+	}
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, row := range sec.rows {
+		grade := 0
+		if sec.colorFunc != nil {
+			grade = sec.colorFunc(row)
+		}
+		if hex, ok := theme.GradeColors[grade]; ok {
+			pdf.SetFillColor(hexToRGB(hex))
+		} else {
+			pdf.SetFillColor(255, 255, 255)
+		}
+		for i, v := range row {
+			ln := 0
+			if i == len(row)-1 {
+				ln = 1
+			}
+			pdf.CellFormat(colWidth, 8, formatCellPDF(v), "1", ln, "C", true, 0, "")
+		}
+	}
+	pdf.Ln(6)
+}
+
+// jsonSection is the wire shape of one exportSection: rows keep their
+// column order (a JSON object per row would lose it, since map key order
+// isn't stable), so each row is serialized as a plain array matching
+// headers position-for-position.
+type jsonSection struct {
+	Title   string   `json:"title,omitempty"`
+	Headers []string `json:"headers"`
+	Rows    []Row    `json:"rows"`
+}
+
+// writeJSON renders every section as a JSON array of {title, headers,
+// rows} objects, so the same grade-scale/student-results/combined
+// sections driving CSV/XLSX/PDF/ODS also serve gradebook integrations
+// that want structured data instead of a spreadsheet.
+func (b *ExportBuilder) writeJSON(w io.Writer) error {
+	sections := make([]jsonSection, len(b.sections))
+	for i, sec := range b.sections {
+		sections[i] = jsonSection{Title: sec.title, Headers: sec.headers, Rows: sec.rows}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sections)
+}
+
+// formatCellPDF renders a cell value as PDF table text.
+func formatCellPDF(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case int:
+		return strconv.Itoa(t)
+	case float64:
+		return formatFloat(t, 2, dialectDefault)
+	default:
+		return fmt.Sprintf("%v", t)
+	}
+}
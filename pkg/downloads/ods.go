@@ -0,0 +1,150 @@
+package downloads
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// odsMimetype is both the content of the ODS zip's first entry and its
+// Content-Type; ODF readers require that entry to be stored uncompressed
+// and written before anything else in the archive.
+const odsMimetype = "application/vnd.oasis.opendocument.spreadsheet"
+
+const odsManifestXML = `<?xml version="1.0" encoding="UTF-8"?>
+<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">
+ <manifest:file-entry manifest:full-path="/" manifest:version="1.2" manifest:media-type="application/vnd.oasis.opendocument.spreadsheet"/>
+ <manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>
+</manifest:manifest>
+`
+
+const odsContentHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" office:version="1.2">
+ <office:automatic-styles>
+  <style:style style:name="ce-header" style:family="table-cell">
+   <style:text-properties fo:font-weight="bold"/>
+  </style:style>
+`
+
+const odsContentFooterOpenBody = ` </office:automatic-styles>
+ <office:body>
+  <office:spreadsheet>
+`
+
+const odsContentFooter = `  </office:spreadsheet>
+ </office:body>
+</office:document-content>
+`
+
+// writeODS renders every section as its own table (sheet) of an
+// OpenDocument Spreadsheet, using the archive/zip and encoding/xml
+// standard library packages directly rather than a third-party ODF
+// library.
+func (b *ExportBuilder) writeODS(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	mimeWriter, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := mimeWriter.Write([]byte(odsMimetype)); err != nil {
+		return err
+	}
+
+	manifestWriter, err := zw.Create("META-INF/manifest.xml")
+	if err != nil {
+		return err
+	}
+	if _, err := manifestWriter.Write([]byte(odsManifestXML)); err != nil {
+		return err
+	}
+
+	contentWriter, err := zw.Create("content.xml")
+	if err != nil {
+		return err
+	}
+	if _, err := contentWriter.Write([]byte(b.renderODSContent())); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// renderODSContent builds content.xml: one grade-colored table-cell style
+// per grade (reusing the builder's theme, the same palette as the Excel
+// exports), then one table:table per section.
+func (b *ExportBuilder) renderODSContent() string {
+	var buf strings.Builder
+	buf.WriteString(odsContentHeader)
+	for grade, hex := range b.theme.GradeColors {
+		fmt.Fprintf(&buf, "  <style:style style:name=\"ce-grade%d\" style:family=\"table-cell\">\n", grade)
+		fmt.Fprintf(&buf, "   <style:table-cell-properties fo:background-color=\"%s\"/>\n", hex)
+		buf.WriteString("  </style:style>\n")
+	}
+	buf.WriteString(odsContentFooterOpenBody)
+
+	for i, sec := range b.sections {
+		sheetName := sec.title
+		if sheetName == "" {
+			sheetName = fmt.Sprintf("Sheet%d", i+1)
+		}
+		fmt.Fprintf(&buf, "   <table:table table:name=%q>\n", odsEscape(sheetName))
+
+		buf.WriteString("    <table:table-row>\n")
+		for _, h := range sec.headers {
+			fmt.Fprintf(&buf, "     <table:table-cell table:style-name=\"ce-header\" office:value-type=\"string\"><text:p>%s</text:p></table:table-cell>\n", odsEscape(h))
+		}
+		buf.WriteString("    </table:table-row>\n")
+
+		for _, row := range sec.rows {
+			grade := 0
+			if sec.colorFunc != nil {
+				grade = sec.colorFunc(row)
+			}
+			styleAttr := ""
+			if _, ok := b.theme.GradeColors[grade]; ok {
+				styleAttr = fmt.Sprintf(" table:style-name=\"ce-grade%d\"", grade)
+			}
+			buf.WriteString("    <table:table-row>\n")
+			for _, v := range row {
+				writeODSCell(&buf, v, styleAttr)
+			}
+			buf.WriteString("    </table:table-row>\n")
+		}
+
+		buf.WriteString("   </table:table>\n")
+	}
+
+	buf.WriteString(odsContentFooter)
+	return buf.String()
+}
+
+// writeODSCell appends a single table:table-cell element, choosing the
+// office:value-type that lets ODF readers treat numbers as numbers rather
+// than text.
+func writeODSCell(buf *strings.Builder, v interface{}, styleAttr string) {
+	switch t := v.(type) {
+	case nil:
+		fmt.Fprintf(buf, "     <table:table-cell%s/>\n", styleAttr)
+	case string:
+		fmt.Fprintf(buf, "     <table:table-cell%s office:value-type=\"string\"><text:p>%s</text:p></table:table-cell>\n", styleAttr, odsEscape(t))
+	case int:
+		fmt.Fprintf(buf, "     <table:table-cell%s office:value-type=\"float\" office:value=\"%d\"><text:p>%d</text:p></table:table-cell>\n", styleAttr, t, t)
+	case float64:
+		s := strconv.FormatFloat(t, 'f', 2, 64)
+		fmt.Fprintf(buf, "     <table:table-cell%s office:value-type=\"float\" office:value=\"%s\"><text:p>%s</text:p></table:table-cell>\n", styleAttr, s, s)
+	default:
+		fmt.Fprintf(buf, "     <table:table-cell%s office:value-type=\"string\"><text:p>%s</text:p></table:table-cell>\n", styleAttr, odsEscape(fmt.Sprintf("%v", t)))
+	}
+}
+
+// odsEscape escapes a cell's text content for inclusion in content.xml.
+func odsEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,148 @@
+package downloads
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/payback159/notenschluessel/pkg/models"
+	"github.com/payback159/notenschluessel/pkg/session"
+)
+
+func TestExportBuilder_WriteODS_ValidZip(t *testing.T) {
+	data := models.PageData{
+		HasResults: true,
+		GradeBounds: []models.GradeBound{
+			{Grade: 1, LowerBound: 85, UpperBound: 100},
+			{Grade: 2, LowerBound: 70, UpperBound: 84.5},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := NewExport(data).WithGradeScale().Format(FormatODS).Write(&buf); err != nil {
+		t.Fatalf("writeODS failed: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("not a valid zip archive: %v", err)
+	}
+
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	for _, want := range []string{"mimetype", "META-INF/manifest.xml", "content.xml"} {
+		found := false
+		for _, n := range names {
+			if n == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("expected zip entry %q, got %v", want, names)
+		}
+	}
+
+	if zr.File[0].Name != "mimetype" || zr.File[0].Method != zip.Store {
+		t.Errorf("mimetype must be the first entry and stored uncompressed, got name=%s method=%d", zr.File[0].Name, zr.File[0].Method)
+	}
+}
+
+func TestHandleDownload_NoSession(t *testing.T) {
+	store := session.NewStore()
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/download?type=grade-scale&format=csv", nil)
+
+	HandleDownload(w, req, store)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want 400, got %d", w.Code)
+	}
+}
+
+func TestHandleDownload_UnknownFormat(t *testing.T) {
+	sid := "sid-download-unknown-format"
+	store := newTestStore(sid, models.PageData{HasResults: true})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/download?type=grade-scale&format=exotic", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+
+	HandleDownload(w, req, store)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want 400, got %d", w.Code)
+	}
+}
+
+func TestHandleDownload_ODS(t *testing.T) {
+	sid := "sid-download-ods"
+	data := models.PageData{
+		HasResults: true,
+		GradeBounds: []models.GradeBound{
+			{Grade: 1, LowerBound: 85, UpperBound: 100},
+		},
+	}
+	store := newTestStore(sid, data)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/download?type=grade-scale&format=ods", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+
+	HandleDownload(w, req, store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != odsMimetype {
+		t.Errorf("Content-Type: got %s", ct)
+	}
+	if w.Body.Len() == 0 {
+		t.Error("expected non-empty ODS body")
+	}
+}
+
+func TestHandleDownload_TSV(t *testing.T) {
+	sid := "sid-download-tsv"
+	data := models.PageData{
+		HasResults: true,
+		GradeBounds: []models.GradeBound{
+			{Grade: 1, LowerBound: 85, UpperBound: 100},
+		},
+	}
+	store := newTestStore(sid, data)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/download?type=grade-scale&format=tsv", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+
+	HandleDownload(w, req, store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", w.Code)
+	}
+	body := strings.TrimPrefix(w.Body.String(), utf8BOM)
+	if !strings.Contains(body, "Note\tPunktebereich von\tPunktebereich bis") {
+		t.Errorf("expected tab-delimited header, got %q", body)
+	}
+}
+
+func TestHandleDownload_StudentResultsWithoutStudents(t *testing.T) {
+	sid := "sid-download-no-students"
+	store := newTestStore(sid, models.PageData{HasResults: true, HasStudents: false})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/download?type=student-results&format=csv", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+
+	HandleDownload(w, req, store)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,359 @@
+package downloads
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/payback159/notenschluessel/pkg/calculator"
+	"github.com/payback159/notenschluessel/pkg/logging"
+	"github.com/payback159/notenschluessel/pkg/models"
+	"github.com/payback159/notenschluessel/pkg/security"
+	"github.com/payback159/notenschluessel/pkg/session"
+)
+
+// classStats holds the summary statistics shown at the bottom of the
+// student results PDF.
+type classStats struct {
+	Count    int
+	Mean     float64
+	Median   float64
+	StdDev   float64
+	PassRate float64
+	Min      float64
+	Max      float64
+}
+
+// computeClassStats derives mean/median/stddev/pass-rate/min/max from the
+// final points, delegating median/stddev/pass-rate to
+// calculator.ClassStatistics so the PDF report uses the same scheme-aware
+// pass/fail split as the rest of the app instead of its own assumption.
+func computeClassStats(students []models.Student, bounds []models.GradeBound) classStats {
+	var stats classStats
+	stats.Count = len(students)
+	if stats.Count == 0 {
+		return stats
+	}
+
+	sum := 0.0
+	for _, s := range students {
+		sum += s.Points
+	}
+	stats.Mean = sum / float64(stats.Count)
+
+	cs := calculator.ClassStatistics(students, bounds)
+	stats.Median = cs.Median
+	stats.StdDev = cs.StdDev
+	stats.PassRate = cs.PassRate
+	stats.Min = cs.MinPoints
+	stats.Max = cs.MaxPoints
+
+	return stats
+}
+
+// newReportPDF creates an A4 portrait document with the fonts and margins
+// shared by every report handler below.
+func newReportPDF(title string) *gofpdf.Fpdf {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, title, "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(0, 6, "Erstellt am "+time.Now().Format("02.01.2006 15:04"), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+	return pdf
+}
+
+// drawGradeScaleTable renders the grade scale with the same color coding
+// as the Excel exports.
+func drawGradeScaleTable(pdf *gofpdf.Fpdf, bounds []models.GradeBound, theme Theme, localizer Localizer) {
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 7, localizer.GradeScaleTitle(), "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(0xf2, 0xf2, 0xf2)
+	pdf.CellFormat(30, 8, localizer.GradeHeader(), "1", 0, "C", true, 0, "")
+	pdf.CellFormat(70, 8, localizer.PointsFromHeader(), "1", 0, "C", true, 0, "")
+	pdf.CellFormat(70, 8, localizer.PointsToHeader(), "1", 1, "C", true, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, bound := range bounds {
+		if hex, ok := theme.GradeColors[bound.Grade]; ok {
+			pdf.SetFillColor(hexToRGB(hex))
+		} else {
+			pdf.SetFillColor(255, 255, 255)
+		}
+		pdf.CellFormat(30, 8, fmt.Sprintf("%d", bound.Grade), "1", 0, "C", true, 0, "")
+		pdf.CellFormat(70, 8, formatFloat(bound.LowerBound, 1, dialectDefault), "1", 0, "C", true, 0, "")
+		pdf.CellFormat(70, 8, formatFloat(bound.UpperBound, 1, dialectDefault), "1", 1, "C", true, 0, "")
+	}
+	pdf.Ln(6)
+}
+
+// drawStudentTable renders the student roster with each row tinted by the
+// student's grade, followed by the class average.
+func drawStudentTable(pdf *gofpdf.Fpdf, students []models.Student, averageGrade float64, theme Theme, localizer Localizer) {
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 7, localizer.StudentResultsTitle(), "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(0xf2, 0xf2, 0xf2)
+	pdf.CellFormat(90, 8, localizer.NameHeader(), "1", 0, "L", true, 0, "")
+	pdf.CellFormat(45, 8, localizer.PointsHeader(), "1", 0, "C", true, 0, "")
+	pdf.CellFormat(35, 8, localizer.GradeHeader(), "1", 1, "C", true, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, student := range students {
+		if hex, ok := theme.GradeColors[student.Grade]; ok {
+			pdf.SetFillColor(hexToRGB(hex))
+		} else {
+			pdf.SetFillColor(255, 255, 255)
+		}
+		pdf.CellFormat(90, 8, student.Name, "1", 0, "L", true, 0, "")
+		pdf.CellFormat(45, 8, formatFloat(student.Points, 1, dialectDefault), "1", 0, "C", true, 0, "")
+		pdf.CellFormat(35, 8, fmt.Sprintf("%d", student.Grade), "1", 1, "C", true, 0, "")
+	}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(255, 255, 255)
+	pdf.CellFormat(90, 8, localizer.AverageLabel(), "1", 0, "L", false, 0, "")
+	pdf.CellFormat(45, 8, "", "1", 0, "C", false, 0, "")
+	pdf.CellFormat(35, 8, formatFloat(averageGrade, 2, dialectDefault), "1", 1, "C", false, 0, "")
+	pdf.Ln(6)
+}
+
+// drawGradeHistogram draws a simple bar chart of how many students received
+// each grade, using the same color coding as the tables above. The bars are
+// ordered by the theme's grade numbers (ascending) so a 6-tier theme shows
+// all six bars instead of the Austrian default's five; the bar width shrinks
+// to keep the whole row within the page's printable width regardless of how
+// many grades the theme has.
+func drawGradeHistogram(pdf *gofpdf.Fpdf, students []models.Student, theme Theme, localizer Localizer) {
+	counts := make(map[int]int, len(theme.GradeColors))
+	maxCount := 0
+	for _, s := range students {
+		counts[s.Grade]++
+		if counts[s.Grade] > maxCount {
+			maxCount = counts[s.Grade]
+		}
+	}
+	if maxCount == 0 {
+		return
+	}
+
+	grades := make([]int, 0, len(theme.GradeColors))
+	for grade := range theme.GradeColors {
+		grades = append(grades, grade)
+	}
+	sort.Ints(grades)
+
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 7, localizer.GradeDistributionTitle(), "", 1, "L", false, 0, "")
+
+	const printableWidth, barGap, maxBarHeight = 180.0, 10.0, 40.0
+	barWidth := (printableWidth - barGap*float64(len(grades)-1)) / float64(len(grades))
+	if barWidth > 25.0 {
+		barWidth = 25.0
+	}
+	x0, y0 := pdf.GetXY()
+	baseline := y0 + maxBarHeight + 6
+
+	for i, grade := range grades {
+		count := counts[grade]
+		height := 0.0
+		if maxCount > 0 {
+			height = float64(count) / float64(maxCount) * maxBarHeight
+		}
+		x := x0 + float64(i)*(barWidth+barGap)
+
+		if hex, ok := theme.GradeColors[grade]; ok {
+			pdf.SetFillColor(hexToRGB(hex))
+		}
+		pdf.Rect(x, baseline-height, barWidth, height, "F")
+		pdf.Rect(x, baseline-height, barWidth, height, "D")
+
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetXY(x, baseline+1)
+		pdf.CellFormat(barWidth, 5, fmt.Sprintf("%s %d: %d", localizer.GradeHeader(), grade, count), "", 0, "C", false, 0, "")
+	}
+
+	pdf.SetXY(x0, baseline+8)
+	pdf.Ln(2)
+}
+
+// drawClassStats renders the mean/median/stddev/pass-rate/min/max summary.
+func drawClassStats(pdf *gofpdf.Fpdf, stats classStats) {
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 7, "Statistik", "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	rows := []string{
+		fmt.Sprintf("Anzahl Schüler: %d", stats.Count),
+		fmt.Sprintf("Durchschnitt (Punkte): %s", formatFloat(stats.Mean, 2, dialectDefault)),
+		fmt.Sprintf("Median (Punkte): %s", formatFloat(stats.Median, 2, dialectDefault)),
+		fmt.Sprintf("Standardabweichung: %s", formatFloat(stats.StdDev, 2, dialectDefault)),
+		fmt.Sprintf("Bestehensquote: %s %%", formatFloat(stats.PassRate, 1, dialectDefault)),
+		fmt.Sprintf("Minimum: %s", formatFloat(stats.Min, 1, dialectDefault)),
+		fmt.Sprintf("Maximum: %s", formatFloat(stats.Max, 1, dialectDefault)),
+	}
+	for _, row := range rows {
+		pdf.CellFormat(0, 6, row, "", 1, "L", false, 0, "")
+	}
+}
+
+// HandleGradeScalePDF renders the grade scale table as a PDF, for teachers
+// who want to archive or print it without opening the XLSX export.
+func HandleGradeScalePDF(w http.ResponseWriter, r *http.Request, sessionStore session.Store) {
+	start := time.Now()
+	sessionID := getSessionIDFromCookie(r)
+	ip := security.GetClientIP(r)
+	theme := ResolveThemeFromRequest(r)
+	localizer := ResolveLocalizerFromRequest(r)
+
+	logging.LogInfo("Grade scale PDF download requested",
+		"session_id", sessionID,
+		"ip", ip)
+
+	data, exists, err := sessionStore.GetContext(r.Context(), sessionID)
+	if err != nil {
+		logging.LogWarn("Session lookup aborted",
+			"session_id", sessionID,
+			"ip", ip,
+			"error", err.Error())
+		http.Error(w, "Keine Daten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
+	if !exists || !data.HasResults {
+		logging.LogWarn("PDF grade scale download requested but no data available",
+			"session_id", sessionID,
+			"ip", ip)
+		http.Error(w, "Keine Daten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
+
+	pdf := newReportPDF(localizer.GradeScaleTitle())
+	drawGradeScaleTable(pdf, data.GradeBounds, theme, localizer)
+
+	setDownloadHeaders(w, "application/pdf", "notenschluessel.pdf")
+	cw := &countingWriter{w: w}
+	if err := pdf.Output(cw); err != nil {
+		logging.LogError("Failed to write grade scale PDF", err, "session_id", sessionID, "ip", ip)
+		http.Error(w, "Fehler beim Erstellen der PDF-Datei", http.StatusInternalServerError)
+		return
+	}
+
+	duration := time.Since(start)
+	logging.LogFileOperation("pdf_download", "notenschluessel.pdf", cw.bytes, duration, true,
+		"session_id", sessionID,
+		"ip", ip,
+		"grade_count", len(data.GradeBounds))
+}
+
+// HandleStudentResultsPDF renders the student roster, grade histogram and
+// class statistics as a single PDF report.
+func HandleStudentResultsPDF(w http.ResponseWriter, r *http.Request, sessionStore session.Store) {
+	start := time.Now()
+	sessionID := getSessionIDFromCookie(r)
+	ip := security.GetClientIP(r)
+	theme := ResolveThemeFromRequest(r)
+	localizer := ResolveLocalizerFromRequest(r)
+
+	logging.LogInfo("Student results PDF download requested",
+		"session_id", sessionID,
+		"ip", ip)
+
+	data, exists, err := sessionStore.GetContext(r.Context(), sessionID)
+	if err != nil {
+		logging.LogWarn("Session lookup aborted",
+			"session_id", sessionID,
+			"ip", ip,
+			"error", err.Error())
+		http.Error(w, "Keine Daten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
+	if !exists || !data.HasStudents {
+		logging.LogWarn("PDF student results download requested but no data available",
+			"session_id", sessionID,
+			"ip", ip)
+		http.Error(w, "Keine Schülerdaten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
+
+	pdf := newReportPDF(localizer.StudentResultsTitle())
+	drawStudentTable(pdf, data.Students, data.AverageGrade, theme, localizer)
+	drawGradeHistogram(pdf, data.Students, theme, localizer)
+	drawClassStats(pdf, computeClassStats(data.Students, data.GradeBounds))
+
+	setDownloadHeaders(w, "application/pdf", "schueler_ergebnisse.pdf")
+	cw := &countingWriter{w: w}
+	if err := pdf.Output(cw); err != nil {
+		logging.LogError("Failed to write student results PDF", err, "session_id", sessionID, "ip", ip)
+		http.Error(w, "Fehler beim Erstellen der PDF-Datei", http.StatusInternalServerError)
+		return
+	}
+
+	duration := time.Since(start)
+	logging.LogFileOperation("pdf_download", "schueler_ergebnisse.pdf", cw.bytes, duration, true,
+		"session_id", sessionID,
+		"ip", ip,
+		"student_count", len(data.Students))
+}
+
+// HandleCombinedPDF renders the grade scale, student roster, grade
+// histogram and class statistics as a single teacher-ready PDF report.
+func HandleCombinedPDF(w http.ResponseWriter, r *http.Request, sessionStore session.Store) {
+	start := time.Now()
+	sessionID := getSessionIDFromCookie(r)
+	ip := security.GetClientIP(r)
+	theme := ResolveThemeFromRequest(r)
+	localizer := ResolveLocalizerFromRequest(r)
+
+	logging.LogInfo("Combined PDF download requested",
+		"session_id", sessionID,
+		"ip", ip)
+
+	data, exists, err := sessionStore.GetContext(r.Context(), sessionID)
+	if err != nil {
+		logging.LogWarn("Session lookup aborted",
+			"session_id", sessionID,
+			"ip", ip,
+			"error", err.Error())
+		http.Error(w, "Keine Daten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
+	if !exists || !data.HasResults {
+		logging.LogWarn("PDF combined download requested but no data available",
+			"session_id", sessionID,
+			"ip", ip)
+		http.Error(w, "Keine Daten zum Herunterladen verfügbar", http.StatusBadRequest)
+		return
+	}
+
+	pdf := newReportPDF(localizer.GradeScaleTitle() + " & " + localizer.StudentResultsTitle())
+	drawGradeScaleTable(pdf, data.GradeBounds, theme, localizer)
+
+	if data.HasStudents {
+		drawStudentTable(pdf, data.Students, data.AverageGrade, theme, localizer)
+		drawGradeHistogram(pdf, data.Students, theme, localizer)
+		drawClassStats(pdf, computeClassStats(data.Students, data.GradeBounds))
+	}
+
+	setDownloadHeaders(w, "application/pdf", "notenschluessel_komplett.pdf")
+	cw := &countingWriter{w: w}
+	if err := pdf.Output(cw); err != nil {
+		logging.LogError("Failed to write combined PDF", err, "session_id", sessionID, "ip", ip)
+		http.Error(w, "Fehler beim Erstellen der PDF-Datei", http.StatusInternalServerError)
+		return
+	}
+
+	duration := time.Since(start)
+	logging.LogFileOperation("pdf_download", "notenschluessel_komplett.pdf", cw.bytes, duration, true,
+		"session_id", sessionID,
+		"ip", ip,
+		"has_students", data.HasStudents,
+		"grade_count", len(data.GradeBounds),
+		"student_count", len(data.Students))
+}
@@ -0,0 +1,138 @@
+package downloads
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/payback159/notenschluessel/pkg/models"
+	"github.com/payback159/notenschluessel/pkg/session"
+)
+
+// --- PDF handlers (basic smoke tests) ---
+
+func TestHandleGradeScalePDF_NoSession(t *testing.T) {
+	store := session.NewStore()
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/download/grade-scale-pdf", nil)
+
+	HandleGradeScalePDF(w, req, store)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want 400, got %d", w.Code)
+	}
+}
+
+func TestHandleGradeScalePDF_WithData(t *testing.T) {
+	sid := "sid-pdf-grades"
+	data := models.PageData{
+		HasResults: true,
+		GradeBounds: []models.GradeBound{
+			{Grade: 1, LowerBound: 85, UpperBound: 100},
+			{Grade: 2, LowerBound: 70, UpperBound: 84.5},
+			{Grade: 3, LowerBound: 55, UpperBound: 69.5},
+			{Grade: 4, LowerBound: 40, UpperBound: 54.5},
+			{Grade: 5, LowerBound: 0, UpperBound: 39.5},
+		},
+	}
+	store := newTestStore(sid, data)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/download/grade-scale-pdf", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+
+	HandleGradeScalePDF(w, req, store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/pdf" {
+		t.Errorf("Content-Type: got %s", ct)
+	}
+	if w.Body.Len() == 0 {
+		t.Error("expected non-empty PDF body")
+	}
+}
+
+func TestHandleStudentResultsPDF_WithStudents(t *testing.T) {
+	sid := "sid-pdf-students"
+	data := models.PageData{
+		HasResults:  true,
+		HasStudents: true,
+		Students: []models.Student{
+			{Name: "Alice", Points: 90, Grade: 1},
+			{Name: "Bob", Points: 60, Grade: 3},
+			{Name: "Carol", Points: 20, Grade: 5},
+		},
+		AverageGrade: 3.0,
+	}
+	store := newTestStore(sid, data)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/download/student-results-pdf", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+
+	HandleStudentResultsPDF(w, req, store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", w.Code)
+	}
+	if w.Body.Len() == 0 {
+		t.Error("expected non-empty PDF body")
+	}
+}
+
+func TestHandleCombinedPDF_WithData(t *testing.T) {
+	sid := "sid-pdf-combined"
+	data := models.PageData{
+		HasResults:  true,
+		HasStudents: true,
+		GradeBounds: []models.GradeBound{
+			{Grade: 1, LowerBound: 85, UpperBound: 100},
+			{Grade: 2, LowerBound: 70, UpperBound: 84.5},
+		},
+		Students: []models.Student{
+			{Name: "Alice", Points: 90, Grade: 1},
+		},
+		AverageGrade: 1.0,
+	}
+	store := newTestStore(sid, data)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/download/combined-pdf", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+
+	HandleCombinedPDF(w, req, store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", w.Code)
+	}
+}
+
+func TestComputeClassStats(t *testing.T) {
+	students := []models.Student{
+		{Name: "Alice", Points: 90, Grade: 1},
+		{Name: "Bob", Points: 60, Grade: 3},
+		{Name: "Carol", Points: 20, Grade: 5},
+	}
+
+	stats := computeClassStats(students, nil)
+
+	if stats.Count != 3 {
+		t.Errorf("Count: got %d, want 3", stats.Count)
+	}
+	if stats.Min != 20 || stats.Max != 90 {
+		t.Errorf("Min/Max: got %v/%v, want 20/90", stats.Min, stats.Max)
+	}
+	wantPassRate := 66.67 // ClassStatistics rounds to 2 decimal places
+	if stats.PassRate != wantPassRate {
+		t.Errorf("PassRate: got %v, want %v", stats.PassRate, wantPassRate)
+	}
+}
+
+func TestComputeClassStats_Empty(t *testing.T) {
+	stats := computeClassStats(nil, nil)
+	if stats.Count != 0 {
+		t.Errorf("expected zero-value stats for empty input, got %+v", stats)
+	}
+}
@@ -0,0 +1,198 @@
+package downloads
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Theme supplies the per-grade fill color used by every export format, so a
+// school using a different grading scale (grade count or direction) still
+// gets a best-to-worst green-to-red gradient instead of the hardcoded
+// Austrian 1-5 palette.
+type Theme struct {
+	Name        string
+	GradeColors map[int]string // grade number -> hex fill color
+}
+
+// gradeColorSpectrum is the shared green-to-red gradient every built-in
+// theme slices from, best grade first.
+var gradeColorSpectrum = []string{
+	"#c6f6d5",
+	"#d4edda",
+	"#fff3cd",
+	"#ffe8cc",
+	"#f8d7da",
+	"#f1aeb5",
+}
+
+// buildGradeColors maps gradeOrder[i] (the actual grade number at rank i,
+// best first) to the i-th color of the shared spectrum, so themes with a
+// different grade count or direction (e.g. Swiss 6-1, where 6 is best)
+// still color best-to-worst green-to-red.
+func buildGradeColors(gradeOrder []int) map[int]string {
+	colors := make(map[int]string, len(gradeOrder))
+	for i, grade := range gradeOrder {
+		colors[grade] = gradeColorSpectrum[i%len(gradeColorSpectrum)]
+	}
+	return colors
+}
+
+// ThemeAT5 is the Austrian/German 5-tier scale (1 best, 5 worst) and the
+// default theme, matching the original hardcoded palette.
+var ThemeAT5 = Theme{Name: "at5", GradeColors: buildGradeColors([]int{1, 2, 3, 4, 5})}
+
+// ThemeDE6 is the German 6-tier scale (1 best, 6 worst).
+var ThemeDE6 = Theme{Name: "de6", GradeColors: buildGradeColors([]int{1, 2, 3, 4, 5, 6})}
+
+// ThemeCH6 is the Swiss 6-tier scale, inverted: 6 is best, 1 is worst.
+var ThemeCH6 = Theme{Name: "ch6", GradeColors: buildGradeColors([]int{6, 5, 4, 3, 2, 1})}
+
+// ResolveTheme looks up a built-in theme by its "?theme=" query value,
+// defaulting to ThemeAT5 for an empty or unrecognized name.
+func ResolveTheme(name string) Theme {
+	switch name {
+	case "de6":
+		return ThemeDE6
+	case "ch6":
+		return ThemeCH6
+	default:
+		return ThemeAT5
+	}
+}
+
+// ResolveThemeFromRequest reads the "?theme=" query parameter.
+func ResolveThemeFromRequest(r *http.Request) Theme {
+	return ResolveTheme(r.URL.Query().Get("theme"))
+}
+
+// hexToRGB converts a "#rrggbb" color into the RGB triple gofpdf's
+// SetFillColor expects, falling back to white for anything malformed.
+func hexToRGB(hex string) (r, g, b int) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) != 6 {
+		return 255, 255, 255
+	}
+	parsed, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 255, 255, 255
+	}
+	return int(parsed >> 16 & 0xff), int(parsed >> 8 & 0xff), int(parsed & 0xff)
+}
+
+// Localizer supplies the column/sheet headers shown in an export, so the
+// same export pipeline can be read by German, English or French speaking
+// staff without hardcoding any one language.
+type Localizer interface {
+	GradeHeader() string
+	PointsFromHeader() string
+	PointsToHeader() string
+	NameHeader() string
+	PointsHeader() string
+	GradeScaleTitle() string
+	StudentResultsTitle() string
+	AverageLabel() string
+	GradeDistributionTitle() string
+	TendencyHeader() string
+}
+
+// locale is a plain-data Localizer implementation; the built-in locales
+// below are just different field values of the same struct.
+type locale struct {
+	grade, pointsFrom, pointsTo string
+	name, points                string
+	gradeScaleTitle             string
+	studentResultsTitle         string
+	average                     string
+	gradeDistributionTitle      string
+	tendency                    string
+}
+
+func (l locale) GradeHeader() string            { return l.grade }
+func (l locale) PointsFromHeader() string       { return l.pointsFrom }
+func (l locale) PointsToHeader() string         { return l.pointsTo }
+func (l locale) NameHeader() string             { return l.name }
+func (l locale) PointsHeader() string           { return l.points }
+func (l locale) GradeScaleTitle() string        { return l.gradeScaleTitle }
+func (l locale) StudentResultsTitle() string    { return l.studentResultsTitle }
+func (l locale) AverageLabel() string           { return l.average }
+func (l locale) GradeDistributionTitle() string { return l.gradeDistributionTitle }
+func (l locale) TendencyHeader() string         { return l.tendency }
+
+// localeDE matches the strings every handler used before localization was
+// extracted, so German stays the default and existing exports are
+// byte-for-byte unchanged.
+var localeDE = locale{
+	grade:                  "Note",
+	pointsFrom:             "Punktebereich von",
+	pointsTo:               "Punktebereich bis",
+	name:                   "Name",
+	points:                 "Punkte",
+	gradeScaleTitle:        "Notenschlüssel",
+	studentResultsTitle:    "Schülerergebnisse",
+	average:                "Durchschnitt",
+	gradeDistributionTitle: "Notenverteilung",
+	tendency:               "Tendenz",
+}
+
+var localeEN = locale{
+	grade:                  "Grade",
+	pointsFrom:             "Points from",
+	pointsTo:               "Points to",
+	name:                   "Name",
+	points:                 "Points",
+	gradeScaleTitle:        "Grade Scale",
+	studentResultsTitle:    "Student Results",
+	average:                "Average",
+	gradeDistributionTitle: "Grade Distribution",
+	tendency:               "Tendency",
+}
+
+var localeFR = locale{
+	grade:                  "Note",
+	pointsFrom:             "Points à partir de",
+	pointsTo:               "Points jusqu'à",
+	name:                   "Nom",
+	points:                 "Points",
+	gradeScaleTitle:        "Barème de notation",
+	studentResultsTitle:    "Résultats des élèves",
+	average:                "Moyenne",
+	gradeDistributionTitle: "Répartition des notes",
+	tendency:               "Tendance",
+}
+
+// ResolveLocalizer looks up a built-in Localizer by language code
+// ("de"/"en"/"fr"), defaulting to German for anything else.
+func ResolveLocalizer(lang string) Localizer {
+	switch strings.ToLower(lang) {
+	case "en":
+		return localeEN
+	case "fr":
+		return localeFR
+	default:
+		return localeDE
+	}
+}
+
+// ResolveLocalizerFromRequest picks the Localizer from an explicit
+// "?lang=" query parameter, falling back to Accept-Language sniffing and
+// finally German, mirroring resolveDialect's precedence. Only the
+// highest-priority tag (the part before the first comma) is inspected, so a
+// header like "de-DE,de;q=0.9,en;q=0.5" resolves to German rather than
+// matching "en" as a substring of a lower-priority tag.
+func ResolveLocalizerFromRequest(r *http.Request) Localizer {
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		return ResolveLocalizer(lang)
+	}
+
+	if al := r.Header.Get("Accept-Language"); al != "" {
+		primary := strings.ToLower(strings.TrimSpace(strings.SplitN(al, ",", 2)[0]))
+		for _, lang := range []string{"en", "fr", "de"} {
+			if strings.HasPrefix(primary, lang) {
+				return ResolveLocalizer(lang)
+			}
+		}
+	}
+
+	return localeDE
+}
@@ -0,0 +1,87 @@
+package downloads
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolveTheme(t *testing.T) {
+	cases := []struct {
+		name string
+		want Theme
+	}{
+		{"at5", ThemeAT5},
+		{"de6", ThemeDE6},
+		{"ch6", ThemeCH6},
+		{"", ThemeAT5},
+		{"unknown", ThemeAT5},
+	}
+	for _, c := range cases {
+		if got := ResolveTheme(c.name); got.Name != c.want.Name {
+			t.Errorf("ResolveTheme(%q) = %q, want %q", c.name, got.Name, c.want.Name)
+		}
+	}
+}
+
+func TestThemeCH6_IsInverted(t *testing.T) {
+	if ThemeCH6.GradeColors[6] != gradeColorSpectrum[0] {
+		t.Errorf("ch6 grade 6 should get the best (first) color, got %s", ThemeCH6.GradeColors[6])
+	}
+	if ThemeCH6.GradeColors[1] != gradeColorSpectrum[5] {
+		t.Errorf("ch6 grade 1 should get the worst (last) color, got %s", ThemeCH6.GradeColors[1])
+	}
+}
+
+func TestResolveThemeFromRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/download?theme=de6", nil)
+	if got := ResolveThemeFromRequest(req); got.Name != "de6" {
+		t.Errorf("expected de6, got %s", got.Name)
+	}
+}
+
+func TestResolveLocalizer(t *testing.T) {
+	if got := ResolveLocalizer("en").GradeHeader(); got != "Grade" {
+		t.Errorf("expected English header, got %s", got)
+	}
+	if got := ResolveLocalizer("fr").NameHeader(); got != "Nom" {
+		t.Errorf("expected French header, got %s", got)
+	}
+	if got := ResolveLocalizer("xx").GradeHeader(); got != "Note" {
+		t.Errorf("expected German fallback, got %s", got)
+	}
+	if got := ResolveLocalizer("de").TendencyHeader(); got != "Tendenz" {
+		t.Errorf("expected German tendency header, got %s", got)
+	}
+	if got := ResolveLocalizer("en").TendencyHeader(); got != "Tendency" {
+		t.Errorf("expected English tendency header, got %s", got)
+	}
+	if got := ResolveLocalizer("fr").TendencyHeader(); got != "Tendance" {
+		t.Errorf("expected French tendency header, got %s", got)
+	}
+}
+
+func TestResolveLocalizerFromRequest_QueryParamWins(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/download?lang=en", nil)
+	req.Header.Set("Accept-Language", "de-DE,de;q=0.9")
+
+	if got := ResolveLocalizerFromRequest(req).GradeHeader(); got != "Grade" {
+		t.Errorf("expected ?lang= to win over Accept-Language, got %s", got)
+	}
+}
+
+func TestResolveLocalizerFromRequest_AcceptLanguageFallback(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/download", nil)
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+
+	if got := ResolveLocalizerFromRequest(req).GradeHeader(); got != "Grade" {
+		t.Errorf("expected Accept-Language sniffing to pick English, got %s", got)
+	}
+}
+
+func TestHexToRGB(t *testing.T) {
+	r, g, b := hexToRGB("#c6f6d5")
+	if r != 0xc6 || g != 0xf6 || b != 0xd5 {
+		t.Errorf("hexToRGB(#c6f6d5) = %d,%d,%d, want 198,246,213", r, g, b)
+	}
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/payback159/notenschluessel/pkg/calculator"
+	"github.com/payback159/notenschluessel/pkg/logging"
+	"github.com/payback159/notenschluessel/pkg/models"
+	"github.com/payback159/notenschluessel/pkg/security"
+)
+
+// studentInput is the wire shape for a student in the JSON calculation API,
+// mirroring pkg/uploads' studentInput for the batch import endpoints.
+type studentInput struct {
+	Name   string  `json:"name"`
+	Points float64 `json:"points"`
+}
+
+// calculateRequest is the wire shape accepted by POST /api/v1/calculate.
+type calculateRequest struct {
+	MaxPoints         int            `json:"maxPoints"`
+	MinPoints         float64        `json:"minPoints"`
+	BreakPointPercent float64        `json:"breakPointPercent"`
+	Scheme            string         `json:"scheme"`
+	SchemeTierCount   int            `json:"schemeTierCount,omitempty"`
+	Tendencies        bool           `json:"tendencies,omitempty"`
+	Students          []studentInput `json:"students,omitempty"`
+}
+
+// calculateResponse is the wire shape returned by both POST
+// /api/v1/calculate and POST /api/v1/calculate/csv.
+type calculateResponse struct {
+	Scheme         string              `json:"scheme"`
+	ShowTendencies bool                `json:"showTendencies"`
+	GradeBounds    []models.GradeBound `json:"gradeBounds"`
+	Students       []models.Student    `json:"students,omitempty"`
+	AverageGrade   float64             `json:"averageGrade,omitempty"`
+	Stats          *models.ClassStats  `json:"stats,omitempty"`
+}
+
+// studentsFromInput validates a JSON students array, mirroring
+// pkg/uploads' validateStudentInput so imported students are held to the
+// same bounds regardless of entry point.
+func studentsFromInput(inputs []studentInput) ([]models.Student, error) {
+	if len(inputs) > models.MaxStudents {
+		return nil, fmt.Errorf("students exceeds maximum of %d entries", models.MaxStudents)
+	}
+
+	students := make([]models.Student, 0, len(inputs))
+	for i, in := range inputs {
+		name := strings.TrimSpace(in.Name)
+		if name == "" {
+			return nil, fmt.Errorf("students[%d]: name is required", i)
+		}
+		if len(name) > models.MaxNameLength {
+			return nil, fmt.Errorf("students[%d]: name exceeds %d characters", i, models.MaxNameLength)
+		}
+		if in.Points < 0 || in.Points > 1000 {
+			return nil, fmt.Errorf("students[%d]: points out of range (0-1000)", i)
+		}
+		students = append(students, models.Student{Name: security.SanitizeName(name), Points: in.Points})
+	}
+	return students, nil
+}
+
+func toCalculateResponse(result CalculationResponse) calculateResponse {
+	return calculateResponse{
+		Scheme:         result.Scheme,
+		ShowTendencies: result.ShowTendencies,
+		GradeBounds:    result.GradeBounds,
+		Students:       result.Students,
+		AverageGrade:   result.AverageGrade,
+		Stats:          result.Stats,
+	}
+}
+
+// HandleCalculateAPI serves POST /api/v1/calculate: a stateless JSON
+// equivalent of HandleCalculation for programmatic clients (spreadsheet
+// macros, Power Query, Moodle plugins) that need grade bounds and class
+// statistics without driving the HTML form or holding a session.
+func HandleCalculateAPI(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	ip := security.GetClientIP(r)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var wire calculateRequest
+	body := http.MaxBytesReader(w, r.Body, models.MaxFileSize)
+	if err := json.NewDecoder(body).Decode(&wire); err != nil {
+		http.Error(w, fmt.Sprintf("Ungültiges JSON-Format: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	students, err := studentsFromInput(wire.Students)
+	if err != nil {
+		logging.LogWarn("Calculate API request rejected", "error", err.Error(), "ip", ip)
+		http.Error(w, fmt.Sprintf("Ungültige Eingabedaten: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	result, err := Calculate(CalculationRequest{
+		MaxPoints:         wire.MaxPoints,
+		MinPoints:         wire.MinPoints,
+		BreakPointPercent: wire.BreakPointPercent,
+		Scheme:            wire.Scheme,
+		SchemeTierCount:   wire.SchemeTierCount,
+		ShowTendencies:    wire.Tendencies,
+		Students:          students,
+	})
+	if err != nil {
+		logging.LogWarn("Calculate API request rejected", "error", err.Error(), "ip", ip)
+		http.Error(w, fmt.Sprintf("Ungültige Eingabedaten: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	duration := time.Since(start)
+	logging.LogCalculation(wire.MaxPoints, wire.MinPoints, wire.BreakPointPercent, len(students), duration, true)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toCalculateResponse(result)); err != nil {
+		logging.LogError("Failed to encode calculate API response", err, "ip", ip)
+	}
+}
+
+// HandleCalculateCSVAPI serves POST /api/v1/calculate/csv: the same
+// calculation as HandleCalculateAPI, but the roster comes from a multipart
+// "file" upload (CSV or XLSX, auto-detected by calculator.ParseStudents)
+// instead of a JSON students array - the other parameters travel as
+// regular form fields, same as the HTML form.
+func HandleCalculateCSVAPI(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	ip := security.GetClientIP(r)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseMultipartForm(models.MaxFileSize); err != nil {
+		http.Error(w, "Ungültige Upload-Anfrage", http.StatusBadRequest)
+		return
+	}
+
+	calcReq, fieldErr := parseCalculationForm(r)
+	if fieldErr != nil {
+		logging.LogWarn("Calculate CSV API request rejected", "field", fieldErr.Field, "validation_error", fieldErr.Msg, "ip", ip)
+		http.Error(w, fmt.Sprintf("Ungültige Eingabedaten: %v", fieldErr), http.StatusBadRequest)
+		return
+	}
+
+	file, fileHeader, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Keine Datei hochgeladen", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	students, err := calculator.ParseStudents(fileHeader)
+	if err != nil {
+		logging.LogWarn("Calculate CSV API request rejected", "error", err.Error(), "ip", ip)
+		http.Error(w, fmt.Sprintf("Fehler beim Verarbeiten der Datei: %v", err), http.StatusBadRequest)
+		return
+	}
+	calcReq.Students = students
+
+	result, err := Calculate(calcReq)
+	if err != nil {
+		logging.LogWarn("Calculate CSV API request rejected", "error", err.Error(), "ip", ip)
+		http.Error(w, fmt.Sprintf("Ungültige Eingabedaten: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	duration := time.Since(start)
+	logging.LogCalculation(calcReq.MaxPoints, calcReq.MinPoints, calcReq.BreakPointPercent, len(students), duration, true)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toCalculateResponse(result)); err != nil {
+		logging.LogError("Failed to encode calculate CSV API response", err, "ip", ip)
+	}
+}
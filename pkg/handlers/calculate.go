@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/payback159/notenschluessel/pkg/calculator"
+	"github.com/payback159/notenschluessel/pkg/models"
+)
+
+// CalculationRequest carries the parameters needed to compute grade bounds
+// and, optionally, grade a roster of students alongside them - the same
+// inputs the HTML form posts, decoupled from how they arrived (form fields,
+// a JSON body, ...).
+type CalculationRequest struct {
+	MaxPoints         int
+	MinPoints         float64
+	BreakPointPercent float64
+	Scheme            string
+	SchemeTierCount   int  // 0 means "not set"; the scheme falls back to its default
+	ShowTendencies    bool // forces scheme back to SchoolScale5, see Calculate
+	Students          []models.Student
+}
+
+// CalculationResponse is the result of a Calculate call: the resolved
+// scheme and its grade bounds, plus - if Students was provided - their
+// assigned grades and class-wide statistics.
+type CalculationResponse struct {
+	Scheme         string
+	ShowTendencies bool
+	GradeBounds    []models.GradeBound
+	Students       []models.Student
+	AverageGrade   float64
+	Stats          *models.ClassStats
+}
+
+// FieldError reports which CalculationRequest field failed validation, so
+// callers can render a field-specific message instead of a single generic
+// one (HandleCalculation maps it to the matching German form message; the
+// JSON API reports it as-is).
+type FieldError struct {
+	Field string
+	Msg   string
+}
+
+func (e *FieldError) Error() string { return fmt.Sprintf("%s: %s", e.Field, e.Msg) }
+
+// Calculate resolves the grading scheme for req, computes its grade bounds
+// and, if req.Students is non-empty, grades them against those bounds and
+// derives class statistics. It holds the business logic shared by
+// HandleCalculation (the HTML form) and the JSON API; file/form parsing
+// stays with those callers.
+func Calculate(req CalculationRequest) (CalculationResponse, error) {
+	if req.MaxPoints <= 0 || req.MaxPoints > 1000 {
+		return CalculationResponse{}, &FieldError{Field: "maxPoints", Msg: "must be between 1 and 1000"}
+	}
+	if req.MinPoints <= 0 || req.MinPoints > float64(req.MaxPoints) {
+		return CalculationResponse{}, &FieldError{Field: "minPoints", Msg: "must be positive and at most maxPoints"}
+	}
+	if req.BreakPointPercent < 1 || req.BreakPointPercent > 99 {
+		return CalculationResponse{}, &FieldError{Field: "breakPointPercent", Msg: "must be between 1 and 99"}
+	}
+
+	scheme := calculator.ResolveScheme(req.Scheme)
+	schemeParams := map[string]float64{"breakPointPercent": req.BreakPointPercent}
+	if req.SchemeTierCount != 0 {
+		if req.SchemeTierCount < 2 || req.SchemeTierCount > 20 {
+			return CalculationResponse{}, &FieldError{Field: "schemeTierCount", Msg: "must be between 2 and 20"}
+		}
+		schemeParams["n"] = float64(req.SchemeTierCount)
+	}
+
+	// "Tendenzen anzeigen" replaces the chosen scheme with the fifteen-level
+	// IHK/Oberstufe tendency breakdown (1+, 1, 1-, ...), since tendencies are
+	// specific to the German school5 breakpoint model and don't apply to the
+	// other schemes.
+	var gradeBounds []models.GradeBound
+	if req.ShowTendencies {
+		scheme = calculator.SchoolScale5{}
+		gradeBounds = calculator.CalculateTendencyBounds(req.MaxPoints, req.MinPoints, calculator.TendencyConfig{
+			BreakPointPercent: req.BreakPointPercent,
+		})
+	} else {
+		gradeBounds = scheme.ComputeBounds(req.MaxPoints, req.MinPoints, schemeParams)
+	}
+
+	resp := CalculationResponse{
+		Scheme:         scheme.Name(),
+		ShowTendencies: req.ShowTendencies,
+		GradeBounds:    gradeBounds,
+	}
+
+	if len(req.Students) > 0 {
+		students := calculator.AssignGrades(req.Students, gradeBounds)
+		stats := calculator.ClassStatistics(students, gradeBounds)
+
+		resp.Students = students
+		resp.AverageGrade = calculator.CalculateAverageGrade(students)
+		resp.Stats = &stats
+	}
+
+	return resp, nil
+}
@@ -1,14 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 
-	"github.com/gorilla/csrf"
 	"github.com/payback159/notenschluessel/pkg/calculator"
 	"github.com/payback159/notenschluessel/pkg/logging"
 	"github.com/payback159/notenschluessel/pkg/models"
@@ -16,28 +15,76 @@ import (
 	"github.com/payback159/notenschluessel/pkg/session"
 )
 
+// fieldErrorMessages maps a CalculationRequest field name, as reported by a
+// *FieldError, to the German message HandleCalculation has always shown for
+// that field.
+var fieldErrorMessages = map[string]string{
+	"maxPoints":         "Ungültige maximale Punktzahl (1-1000 erlaubt)",
+	"minPoints":         "Ungültige Punkteschrittweite",
+	"breakPointPercent": "Ungültiger Knickpunkt (1-99% erlaubt)",
+	"schemeTierCount":   "Ungültige Anzahl an Notenstufen (2-20 erlaubt)",
+}
+
+func fieldErrorMessage(field string) string {
+	if msg, ok := fieldErrorMessages[field]; ok {
+		return msg
+	}
+	return "Ungültige Eingabe"
+}
+
+// parseCalculationForm reads the calculation parameters from posted form
+// fields, the shape both HandleCalculation and HandleCalculateCSVAPI
+// receive them in. Range validation is Calculate's job; this only reports a
+// *FieldError when a field isn't parseable at all.
+func parseCalculationForm(r *http.Request) (CalculationRequest, *FieldError) {
+	maxPoints, err := strconv.Atoi(r.FormValue("maxPoints"))
+	if err != nil {
+		return CalculationRequest{}, &FieldError{Field: "maxPoints", Msg: "not a valid integer"}
+	}
+
+	minPoints, err := strconv.ParseFloat(r.FormValue("minPoints"), 64)
+	if err != nil {
+		return CalculationRequest{}, &FieldError{Field: "minPoints", Msg: "not a valid number"}
+	}
+
+	breakPointPercent, err := strconv.ParseFloat(r.FormValue("breakPointPercent"), 64)
+	if err != nil {
+		return CalculationRequest{}, &FieldError{Field: "breakPointPercent", Msg: "not a valid number"}
+	}
+
+	req := CalculationRequest{
+		MaxPoints:         maxPoints,
+		MinPoints:         minPoints,
+		BreakPointPercent: breakPointPercent,
+		Scheme:            r.FormValue("scheme"),
+		ShowTendencies:    r.FormValue("tendenzen") == "on",
+	}
+
+	if nStr := r.FormValue("schemeTierCount"); nStr != "" {
+		n, err := strconv.Atoi(nStr)
+		if err != nil {
+			return CalculationRequest{}, &FieldError{Field: "schemeTierCount", Msg: "not a valid integer"}
+		}
+		req.SchemeTierCount = n
+	}
+
+	return req, nil
+}
+
 // Handler holds dependencies for HTTP handlers
 type Handler struct {
 	Templates    *template.Template
-	SessionStore *session.Store
+	SessionStore session.Store
 }
 
 // NewHandler creates a new handler with dependencies
-func NewHandler(templates *template.Template, sessionStore *session.Store) *Handler {
+func NewHandler(templates *template.Template, sessionStore session.Store) *Handler {
 	return &Handler{
 		Templates:    templates,
 		SessionStore: sessionStore,
 	}
 }
 
-// getCSRFField returns CSRF field for production, empty string for development
-func getCSRFField(r *http.Request) template.HTML {
-	if os.Getenv("ENV") == "production" {
-		return csrf.TemplateField(r)
-	}
-	return template.HTML("")
-}
-
 // HandleHome handles the main page requests (GET and POST)
 func (h *Handler) HandleHome(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
@@ -46,9 +93,7 @@ func (h *Handler) HandleHome(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if r.Method == http.MethodGet {
-		pageData := models.PageData{
-			CSRFField: getCSRFField(r),
-		}
+		pageData := models.PageData{}
 		h.Templates.ExecuteTemplate(w, "index.html", pageData)
 		return
 	}
@@ -82,67 +127,56 @@ func (h *Handler) HandleCalculation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pageData := models.PageData{
-		CSRFField: getCSRFField(r),
-	}
-
-	// Parse input parameters
-	maxPointsStr := r.FormValue("maxPoints")
-	minPointsStr := r.FormValue("minPoints")
-	breakPointPercentStr := r.FormValue("breakPointPercent")
+	pageData := models.PageData{}
 
-	maxPoints, err := strconv.Atoi(maxPointsStr)
-	if err != nil || maxPoints <= 0 || maxPoints > 1000 {
+	calcReq, fieldErr := parseCalculationForm(r)
+	if fieldErr != nil {
 		logging.LogWarn("Invalid form input detected",
-			"field", "maxPoints",
-			"validation_error", "out_of_range_or_invalid",
-			"valid_range", "1-1000",
+			"field", fieldErr.Field,
+			"validation_error", fieldErr.Msg,
 			"ip", ip)
 		pageData.Message = &models.Message{
 			Type: models.MessageError,
-			Text: "Ungültige maximale Punktzahl (1-1000 erlaubt)",
+			Text: fieldErrorMessage(fieldErr.Field),
 		}
 		h.Templates.ExecuteTemplate(w, "index.html", pageData)
 		return
 	}
 
-	minPoints, err := strconv.ParseFloat(minPointsStr, 64)
-	if err != nil || minPoints <= 0 || minPoints > float64(maxPoints) {
-		logging.LogWarn("Invalid form input detected",
-			"field", "minPoints",
-			"validation_error", "negative_or_exceeds_max",
-			"constraint", "positive_and_below_max",
-			"ip", ip)
-		pageData.Message = &models.Message{
-			Type: models.MessageError,
-			Text: "Ungültige Punkteschrittweite",
-		}
-		h.Templates.ExecuteTemplate(w, "index.html", pageData)
-		return
-	}
-
-	breakPointPercent, err := strconv.ParseFloat(breakPointPercentStr, 64)
-	if err != nil || breakPointPercent < 1 || breakPointPercent > 99 {
-		logging.LogWarn("Invalid form input detected",
-			"field", "breakPointPercent",
-			"validation_error", "out_of_range",
-			"valid_range", "1-99",
-			"ip", ip)
-		pageData.Message = &models.Message{
-			Type: models.MessageError,
-			Text: "Ungültiger Knickpunkt (1-99% erlaubt)",
+	result, err := Calculate(calcReq)
+	if err != nil {
+		var fieldErr *FieldError
+		if errors.As(err, &fieldErr) {
+			logging.LogWarn("Invalid form input detected",
+				"field", fieldErr.Field,
+				"validation_error", fieldErr.Msg,
+				"ip", ip)
+			pageData.Message = &models.Message{
+				Type: models.MessageError,
+				Text: fieldErrorMessage(fieldErr.Field),
+			}
+		} else {
+			logging.LogError("Calculation failed", err, "ip", ip)
+			pageData.Message = &models.Message{
+				Type: models.MessageError,
+				Text: fmt.Sprintf("Fehler bei der Berechnung: %v", err),
+			}
 		}
 		h.Templates.ExecuteTemplate(w, "index.html", pageData)
 		return
 	}
 
-	// Calculate grade bounds
-	gradeBounds := calculator.CalculateGradeBounds(maxPoints, minPoints, breakPointPercent)
+	maxPoints := calcReq.MaxPoints
+	minPoints := calcReq.MinPoints
+	breakPointPercent := calcReq.BreakPointPercent
+	gradeBounds := result.GradeBounds
 
 	// Set basic page data
 	pageData.MaxPoints = maxPoints
 	pageData.MinPoints = minPoints
 	pageData.BreakPointPercent = breakPointPercent
+	pageData.Scheme = result.Scheme
+	pageData.ShowTendencies = result.ShowTendencies
 	pageData.GradeBounds = gradeBounds
 	pageData.HasResults = true
 	pageData.CalculationSuccess = true
@@ -158,23 +192,25 @@ func (h *Handler) HandleCalculation(w http.ResponseWriter, r *http.Request) {
 			"processing_stage", "parsing",
 			"ip", ip)
 
-		students, err := calculator.ParseCSVFile(fileHeader)
+		students, err := calculator.ParseStudents(fileHeader)
 		if err != nil {
-			logging.LogError("CSV parsing operation failed", err,
+			logging.LogError("Upload parsing operation failed", err,
 				"content_length", fileHeader.Size,
 				"parse_stage", "file_processing",
 				"ip", ip)
 			pageData.Message = &models.Message{
 				Type: models.MessageError,
-				Text: fmt.Sprintf("Fehler beim Verarbeiten der CSV-Datei: %v", err),
+				Text: fmt.Sprintf("Fehler beim Verarbeiten der Datei: %v", err),
 			}
 		} else {
 			// Calculate grades for students
-			students = calculator.ProcessStudents(students, gradeBounds)
+			students = calculator.AssignGrades(students, gradeBounds)
 			averageGrade := calculator.CalculateAverageGrade(students)
+			stats := calculator.ClassStatistics(students, gradeBounds)
 
 			pageData.Students = students
 			pageData.AverageGrade = averageGrade
+			pageData.Stats = &stats
 			pageData.HasStudents = true
 
 			logging.LogInfo("CSV processing completed successfully",
@@ -203,10 +239,26 @@ func (h *Handler) HandleCalculation(w http.ResponseWriter, r *http.Request) {
 			Type: models.MessageError,
 			Text: "Systemfehler bei der Session-Erstellung",
 		}
+	} else if err := h.SessionStore.SetContext(r.Context(), sessionID, pageData); err != nil {
+		logging.LogError("Session storage failed", err,
+			"operation", "session_management",
+			"ip", ip)
+		pageData.Message = &models.Message{
+			Type: models.MessageError,
+			Text: "Systemfehler bei der Session-Erstellung",
+		}
 	} else {
-		h.SessionStore.Set(sessionID, pageData)
 		pageData.SessionID = sessionID
 
+		http.SetCookie(w, &http.Cookie{
+			Name:     "session_id",
+			Value:    sessionID,
+			Path:     "/",
+			MaxAge:   models.SessionTimeout,
+			HttpOnly: true,
+			SameSite: http.SameSiteStrictMode,
+		})
+
 		logging.LogDebug("Session management completed",
 			"session_id_length", len(sessionID),
 			"data_cached", pageData.HasStudents,
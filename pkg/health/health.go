@@ -0,0 +1,107 @@
+// Package health exposes Kubernetes-style liveness and readiness checks.
+// /healthz reports whether the process itself is healthy (templates
+// parsed at startup); /readyz additionally verifies the dependencies a
+// real request needs (session store reachable, disk writable), so a
+// rolling update can tell "started" apart from "can actually serve
+// traffic".
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/payback159/notenschluessel/pkg/logging"
+	"github.com/payback159/notenschluessel/pkg/session"
+)
+
+// checkTimeout bounds how long a single readiness check may take before
+// it's reported as failed, so a wedged dependency can't hang /readyz
+// forever.
+const checkTimeout = 2 * time.Second
+
+// componentStatus is one dependency's check result.
+type componentStatus struct {
+	Status string `json:"status"` // "ok" or "error"
+	Error  string `json:"error,omitempty"`
+}
+
+// response is the JSON body served by both /healthz and /readyz.
+type response struct {
+	Status     string                     `json:"status"` // "ok" or "error"
+	Components map[string]componentStatus `json:"components"`
+}
+
+// HandleLiveness reports whether the process itself is healthy. It only
+// checks in-process state (templates parsed at startup) and never touches
+// the session store or disk, so it keeps reporting healthy even if a
+// downstream dependency the app needs for requests is down - that
+// distinction is what /readyz is for.
+func HandleLiveness(templatesLoaded bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templates := componentStatus{Status: "ok"}
+		if !templatesLoaded {
+			templates = componentStatus{Status: "error", Error: "templates not parsed"}
+		}
+		writeResponse(w, map[string]componentStatus{"templates": templates})
+	}
+}
+
+// HandleReadiness reports whether the instance can actually serve
+// requests: the session store round-trips a lookup, and tempDir (normally
+// os.TempDir()) accepts a scratch file write.
+func HandleReadiness(store session.Store, tempDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, map[string]componentStatus{
+			"session_store": checkSessionStore(r.Context(), store),
+			"disk":          checkDiskWritable(tempDir),
+		})
+	}
+}
+
+// checkSessionStore looks up a session ID that can never exist, which
+// still round-trips through the configured backend (Redis/BoltDB/file/
+// memory) and proves it's reachable, without depending on any real
+// session being present.
+func checkSessionStore(ctx context.Context, store session.Store) componentStatus {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	if _, _, err := store.GetContext(ctx, "healthcheck-probe"); err != nil {
+		return componentStatus{Status: "error", Error: err.Error()}
+	}
+	return componentStatus{Status: "ok"}
+}
+
+func checkDiskWritable(dir string) componentStatus {
+	f, err := os.CreateTemp(dir, "healthcheck-*.tmp")
+	if err != nil {
+		return componentStatus{Status: "error", Error: err.Error()}
+	}
+	name := f.Name()
+	f.Close()
+	if err := os.Remove(name); err != nil {
+		logging.LogWarn("Failed to remove health check temp file", "path", name, "error", err.Error())
+	}
+	return componentStatus{Status: "ok"}
+}
+
+func writeResponse(w http.ResponseWriter, components map[string]componentStatus) {
+	status := "ok"
+	for _, c := range components {
+		if c.Status != "ok" {
+			status = "error"
+			break
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(response{Status: status, Components: components}); err != nil {
+		logging.LogError("Failed to encode health check response", err)
+	}
+}
@@ -5,8 +5,12 @@ import (
 	"log"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
+
+	"github.com/payback159/notenschluessel/pkg/metrics"
 )
 
 var (
@@ -85,25 +89,57 @@ func LogSecurityEvent(event string, severity string, args ...any) {
 	logger.Warn("Security event", allArgs...)
 }
 
-// LogSystemStats logs system statistics and resource usage
-func LogSystemStats() {
+// Stats is a point-in-time snapshot of process health, the same figures
+// LogSystemStats writes to the log, exposed for callers (e.g. the admin
+// stats endpoint) that need the structured values rather than a log line.
+type Stats struct {
+	UptimeSeconds      int    `json:"uptime_seconds"`
+	Uptime             string `json:"uptime"`
+	Goroutines         int    `json:"goroutines"`
+	MemoryAllocMB      uint64 `json:"memory_alloc_mb"`
+	MemoryTotalAllocMB uint64 `json:"memory_total_alloc_mb"`
+	MemorySysMB        uint64 `json:"memory_sys_mb"`
+	GCRuns             uint32 `json:"gc_runs"`
+	NextGCMB           uint64 `json:"next_gc_mb"`
+}
+
+// SystemStats returns the current process health snapshot.
+func SystemStats() Stats {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
 
 	uptime := time.Since(startTime)
 
+	return Stats{
+		UptimeSeconds:      int(uptime.Seconds()),
+		Uptime:             uptime.String(),
+		Goroutines:         runtime.NumGoroutine(),
+		MemoryAllocMB:      bToMb(m.Alloc),
+		MemoryTotalAllocMB: bToMb(m.TotalAlloc),
+		MemorySysMB:        bToMb(m.Sys),
+		GCRuns:             m.NumGC,
+		NextGCMB:           bToMb(m.NextGC),
+	}
+}
+
+// LogSystemStats logs system statistics and resource usage
+func LogSystemStats() {
+	s := SystemStats()
+
 	LogInfo("System statistics",
-		"uptime_seconds", int(uptime.Seconds()),
-		"uptime_str", uptime.String(),
-		"goroutines", runtime.NumGoroutine(),
-		"memory_alloc_mb", bToMb(m.Alloc),
-		"memory_total_alloc_mb", bToMb(m.TotalAlloc),
-		"memory_sys_mb", bToMb(m.Sys),
-		"gc_runs", m.NumGC,
-		"next_gc_mb", bToMb(m.NextGC))
+		"uptime_seconds", s.UptimeSeconds,
+		"uptime_str", s.Uptime,
+		"goroutines", s.Goroutines,
+		"memory_alloc_mb", s.MemoryAllocMB,
+		"memory_total_alloc_mb", s.MemoryTotalAllocMB,
+		"memory_sys_mb", s.MemorySysMB,
+		"gc_runs", s.GCRuns,
+		"next_gc_mb", s.NextGCMB)
 }
 
-// LogHTTPRequest logs HTTP request details
+// LogHTTPRequest logs HTTP request details and records the same event on
+// the notenschluessel_http_requests_total and
+// notenschluessel_http_request_duration_seconds collectors.
 func LogHTTPRequest(method, path, userAgent, ip string, statusCode int, duration time.Duration) {
 	LogInfo("HTTP request",
 		"method", method,
@@ -112,9 +148,12 @@ func LogHTTPRequest(method, path, userAgent, ip string, statusCode int, duration
 		"duration_ms", duration.Milliseconds(),
 		"user_agent", userAgent,
 		"client_ip", ip)
+
+	metrics.RecordHTTPRequest(method, path, statusCode, duration)
 }
 
-// LogFileOperation logs file upload/download operations
+// LogFileOperation logs file upload/download operations and increments the
+// notenschluessel_file_operations_total counter for the same event.
 func LogFileOperation(operation, filename string, size int64, duration time.Duration, success bool, args ...any) {
 	allArgs := append([]any{
 		"operation", operation,
@@ -130,9 +169,20 @@ func LogFileOperation(operation, filename string, size int64, duration time.Dura
 	} else {
 		LogError("File operation failed", fmt.Errorf("operation failed"), allArgs...)
 	}
+
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	if format == "" {
+		format = "unknown"
+	}
+	metrics.RecordFileOperation(operation, format, success)
+
+	if success && strings.HasSuffix(operation, "_download") {
+		metrics.RecordDownloadBytes(format, size)
+	}
 }
 
-// LogCalculation logs grade calculation operations
+// LogCalculation logs grade calculation operations and observes the
+// notenschluessel_calculation_duration_seconds histogram for the same event.
 func LogCalculation(maxPoints int, minPoints float64, breakPoint float64, studentCount int, duration time.Duration, success bool) {
 	LogInfo("Grade calculation",
 		"max_points", maxPoints,
@@ -141,6 +191,15 @@ func LogCalculation(maxPoints int, minPoints float64, breakPoint float64, studen
 		"student_count", studentCount,
 		"duration_ms", duration.Milliseconds(),
 		"success", success)
+
+	metrics.RecordCalculation(duration, success)
+}
+
+// LogCSVParseMetrics records the per-row counters and parse-duration
+// histogram for a single ParseCSVFile call, alongside the generic
+// LogFileOperation entry already logged for the same upload.
+func LogCSVParseMetrics(rowsParsed, rowsSkipped int, duration time.Duration) {
+	metrics.RecordCSVParse(rowsParsed, rowsSkipped, duration)
 }
 
 // Helper function to convert bytes to megabytes
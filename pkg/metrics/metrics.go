@@ -0,0 +1,176 @@
+// Package metrics exposes Prometheus collectors for the HTTP, session and
+// calculation events that pkg/logging already records as structured log
+// lines, so operators get a scrape-friendly surface alongside the logs.
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// The default Prometheus registerer already carries a Go collector and a
+// process collector (see client_golang's prometheus.init), giving us heap,
+// goroutine and GC metrics for free in the shape Grafana's Go dashboards
+// expect - no need to register our own or hand-roll GaugeFuncs for the same
+// numbers.
+
+var (
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notenschluessel_http_requests_total",
+		Help: "Total number of HTTP requests by method, path and status code.",
+	}, []string{"method", "path", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "notenschluessel_http_request_duration_seconds",
+		Help:    "HTTP request duration in seconds by method and path.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "path"})
+
+	calculationDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "notenschluessel_calculation_duration_seconds",
+		Help:    "Duration of grade calculations in seconds.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	calculationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notenschluessel_calculations_total",
+		Help: "Total number of grade calculations by outcome.",
+	}, []string{"success"})
+
+	fileOperationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notenschluessel_file_operations_total",
+		Help: "Total number of file uploads/downloads by operation, format and outcome.",
+	}, []string{"operation", "format", "success"})
+
+	csvRowsParsedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "notenschluessel_csv_rows_parsed_total",
+		Help: "Total number of CSV rows successfully parsed into students.",
+	})
+
+	csvErrorsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "notenschluessel_csv_errors_total",
+		Help: "Total number of CSV rows skipped due to parse or validation errors.",
+	})
+
+	csvParseDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "notenschluessel_csv_parse_duration_seconds",
+		Help:    "Duration of ParseCSVFile calls in seconds.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	activeSessions = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "notenschluessel_active_sessions",
+		Help: "Number of currently active sessions.",
+	})
+
+	uploadsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notenschluessel_uploads_total",
+		Help: "Total number of upload rows/files validated, by outcome.",
+	}, []string{"result"})
+
+	rateLimitRejectionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notenschluessel_ratelimit_rejections_total",
+		Help: "Total number of requests rejected by the rate limiter, by client IP class.",
+	}, []string{"ip_class"})
+
+	downloadBytesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notenschluessel_download_bytes_total",
+		Help: "Total number of bytes shipped in downloads, by format.",
+	}, []string{"format"})
+)
+
+// RecordHTTPRequest increments the request counter and duration histogram
+// for a completed HTTP request.
+func RecordHTTPRequest(method, path string, statusCode int, duration time.Duration) {
+	status := http.StatusText(statusCode)
+	if status == "" {
+		status = "unknown"
+	}
+	httpRequestsTotal.WithLabelValues(method, path, status).Inc()
+	httpRequestDuration.WithLabelValues(method, path).Observe(duration.Seconds())
+}
+
+// RecordCalculation observes the duration of a grade calculation and
+// increments the calculations counter for its outcome.
+func RecordCalculation(duration time.Duration, success bool) {
+	calculationDuration.Observe(duration.Seconds())
+	calculationsTotal.WithLabelValues(strconv.FormatBool(success)).Inc()
+}
+
+// RecordCSVParse increments the CSV row/error counters and observes the
+// CSV-specific parse-duration histogram for one ParseCSVFile call.
+func RecordCSVParse(rowsParsed, rowsSkipped int, duration time.Duration) {
+	csvRowsParsedTotal.Add(float64(rowsParsed))
+	csvErrorsTotal.Add(float64(rowsSkipped))
+	csvParseDuration.Observe(duration.Seconds())
+}
+
+// RecordFileOperation increments the file operation counter for an
+// upload/download of the given format.
+func RecordFileOperation(operation, format string, success bool) {
+	outcome := "true"
+	if !success {
+		outcome = "false"
+	}
+	fileOperationsTotal.WithLabelValues(operation, format, outcome).Inc()
+}
+
+// SetActiveSessions sets the active session gauge, typically fed from
+// session.Store.GetSessionCount().
+func SetActiveSessions(count int) {
+	activeSessions.Set(float64(count))
+}
+
+// RecordUpload increments the upload counter for one validated row or file,
+// where result is typically "ok" or "error".
+func RecordUpload(result string) {
+	uploadsTotal.WithLabelValues(result).Inc()
+}
+
+// RecordRateLimitRejection increments the rate-limit rejection counter for
+// the given client IP class (e.g. "private" or "public").
+func RecordRateLimitRejection(ipClass string) {
+	rateLimitRejectionsTotal.WithLabelValues(ipClass).Inc()
+}
+
+// RecordDownloadBytes adds to the download byte counter for the given
+// format (e.g. "csv", "xlsx", "pdf").
+func RecordDownloadBytes(format string, bytes int64) {
+	downloadBytesTotal.WithLabelValues(format).Add(float64(bytes))
+}
+
+// Handler returns the HTTP handler that serves the Prometheus exposition
+// format for scraping.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// BearerAuth guards next with an optional bearer token from the
+// METRICS_TOKEN environment variable. If unset, next is served
+// unprotected - scraping from a private network with no auth is a normal
+// deployment mode, unlike the admin endpoints which require credentials.
+func BearerAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := os.Getenv("METRICS_TOKEN")
+		if token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		want := "Bearer " + token
+		got := r.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,10 +1,14 @@
 package models
 
+import "time"
+
 // Student represents a student with their name, points and calculated grade
 type Student struct {
-	Name   string
-	Points float64
-	Grade  int
+	Name     string
+	Points   float64
+	Grade    int
+	Tendency string  // "+", "-", or "" when the active scheme doesn't use tendencies
+	Credits  float64 // ECTS-style weight for ClassStats.WeightedAverage; 0 means "unweighted" (counts as 1)
 }
 
 // MessageType defines the type of message (success, error, warning)
@@ -27,6 +31,8 @@ type PageData struct {
 	MaxPoints          int
 	MinPoints          float64
 	BreakPointPercent  float64
+	Scheme             string
+	ShowTendencies     bool
 	GradeBounds        []GradeBound
 	Students           []Student
 	AverageGrade       float64
@@ -35,6 +41,7 @@ type PageData struct {
 	CalculationSuccess bool
 	Message            *Message
 	SessionID          string
+	Stats              *ClassStats
 	// CSRFField removed - using Go 1.25+ native cross-origin protection
 }
 
@@ -43,6 +50,23 @@ type GradeBound struct {
 	Grade      int
 	LowerBound float64
 	UpperBound float64
+	Tendency   string // "+", "-", or "" when the active scheme doesn't use tendencies
+	Failing    bool   // true when this tier counts against PassRate/FailRate
+}
+
+// ClassStats holds class-wide statistics beyond the simple mean
+// CalculateAverageGrade gives: spread, distribution and a credit-weighted
+// Notendurchschnitt.
+type ClassStats struct {
+	Median           float64
+	StdDev           float64
+	MinPoints        float64
+	MaxPoints        float64
+	GradeCounts      map[int]int     // grade -> student count
+	GradePercentages map[int]float64 // grade -> percentage of the class (0-100)
+	WeightedAverage  float64         // grade average weighted by Student.Credits
+	PassRate         float64         // percentage of students whose grade isn't a GradeBound.Failing tier
+	FailRate         float64         // percentage of students whose grade is a GradeBound.Failing tier
 }
 
 // Constants for security limits
@@ -53,4 +77,9 @@ const (
 	SessionTimeout = 24 * 60 * 60 // 24 hours in seconds
 	RateLimit      = 10           // requests per minute
 	RateBurst      = 20           // burst capacity
+
+	DownloadRateLimit = 30 // requests per minute, downloads are read-only and hit less often than the form
+	DownloadRateBurst = 60 // burst capacity
+
+	RateLimiterTTL = 10 * time.Minute // idle per-IP limiters are evicted after this long
 )
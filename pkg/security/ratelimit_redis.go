@@ -0,0 +1,81 @@
+package security
+
+import (
+	"context"
+	"time"
+
+	"github.com/payback159/notenschluessel/pkg/logging"
+	"github.com/redis/go-redis/v9"
+)
+
+// rateLimitWindow is the fixed window a Redis-backed counter covers before
+// its key expires and counting starts over, matching the "per minute"
+// granularity the in-memory Policy.RatePerMinute already uses.
+const rateLimitWindow = time.Minute
+
+// redisRateLimitBackend implements rateLimitBackend with a fixed-window
+// counter in Redis: each policy+IP pair increments a key covering a
+// one-minute window and is denied once it exceeds RatePerMinute+Burst,
+// with Redis' own key TTL reclaiming the counter at the window's end. This
+// is a coarser approximation of the in-memory token bucket (a client can
+// burst up to roughly twice the limit across a window boundary) but keeps
+// every replica's count in lockstep without a shared bookkeeping goroutine.
+type redisRateLimitBackend struct {
+	client *redis.Client
+	prefix string
+}
+
+// newRedisRateLimitBackend creates a redisRateLimitBackend and verifies
+// connectivity with a PING.
+func newRedisRateLimitBackend(addr string) (*redisRateLimitBackend, error) {
+	client := redis.NewClient(&redis.Options{Addr: addr})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, err
+	}
+
+	return &redisRateLimitBackend{
+		client: client,
+		prefix: "notenschluessel:ratelimit:",
+	}, nil
+}
+
+func (b *redisRateLimitBackend) key(policyName, ip string) string {
+	return b.prefix + policyName + ":" + ip
+}
+
+// allow increments the counter for policyName+ip, arming its TTL on first
+// use, and compares the result against the policy's combined rate+burst
+// allowance. A Redis error fails open (allows the request) rather than
+// taking the whole service down if Redis is briefly unreachable.
+func (b *redisRateLimitBackend) allow(ctx context.Context, policyName, ip string, policy Policy) (bool, time.Duration) {
+	key := b.key(policyName, ip)
+
+	count, err := b.client.Incr(ctx, key).Result()
+	if err != nil {
+		logging.LogError("Failed to increment Redis rate limit counter, allowing request", err, "policy", policyName, "ip", ip)
+		return true, 0
+	}
+	if count == 1 {
+		if err := b.client.Expire(ctx, key, rateLimitWindow).Err(); err != nil {
+			logging.LogError("Failed to set Redis rate limit TTL", err, "policy", policyName, "ip", ip)
+		}
+	}
+
+	limit := int64(policy.RatePerMinute) + int64(policy.Burst)
+	if count <= limit {
+		return true, 0
+	}
+
+	ttl, err := b.client.TTL(ctx, key).Result()
+	if err != nil || ttl < 0 {
+		ttl = rateLimitWindow
+	}
+	return false, ttl
+}
+
+func (b *redisRateLimitBackend) close() error {
+	return b.client.Close()
+}
@@ -1,62 +1,257 @@
 package security
 
 import (
+	"context"
 	"fmt"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/payback159/notenschluessel/pkg/logging"
+	"github.com/payback159/notenschluessel/pkg/metrics"
 	"github.com/payback159/notenschluessel/pkg/models"
 	"golang.org/x/time/rate"
 )
 
-// RateLimiter manages rate limiting per IP address
+// Policy configures how a named rate-limiting policy behaves: the allowed
+// rate and burst per IP, and how long an idle IP's limiter is kept around
+// before the janitor evicts it.
+type Policy struct {
+	RatePerMinute float64
+	Burst         int
+	TTL           time.Duration
+}
+
+// ipLimiter pairs a per-IP token bucket with the last time it was used, so
+// the janitor can tell which entries are idle and safe to evict.
+type ipLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// rateLimitBackend lets RateLimiter's accounting be swapped from per-process
+// in-memory token buckets to a shared store, so multiple replicas behind a
+// load balancer enforce one combined limit per IP instead of each allowing
+// RatePerMinute independently. A nil backend (the default) keeps the
+// original in-memory behavior.
+type rateLimitBackend interface {
+	allow(ctx context.Context, policyName, ip string, policy Policy) (allowed bool, retryAfter time.Duration)
+	close() error
+}
+
+// RateLimiter manages rate limiting per IP address, per named policy (e.g.
+// "upload" vs "download"), so different routes can carry different limits
+// without each needing its own limiter map.
 type RateLimiter struct {
-	limiters map[string]*rate.Limiter
+	policies map[string]Policy
+	limiters map[string]*ipLimiter
 	mutex    sync.RWMutex
+	backend  rateLimitBackend
 }
 
-// NewRateLimiter creates a new rate limiter
+// NewRateLimiter creates a new in-memory rate limiter with the built-in
+// "default", "upload" and "download" policies. Call Start to run the
+// idle-eviction janitor once the limiter is wired into main.go.
 func NewRateLimiter() *RateLimiter {
 	return &RateLimiter{
-		limiters: make(map[string]*rate.Limiter),
+		policies: map[string]Policy{
+			"default":  {RatePerMinute: models.RateLimit, Burst: models.RateBurst, TTL: models.RateLimiterTTL},
+			"upload":   {RatePerMinute: models.RateLimit, Burst: models.RateBurst, TTL: models.RateLimiterTTL},
+			"download": {RatePerMinute: models.DownloadRateLimit, Burst: models.DownloadRateBurst, TTL: models.RateLimiterTTL},
+		},
+		limiters: make(map[string]*ipLimiter),
+	}
+}
+
+// NewRateLimiterFromEnv builds a RateLimiter from the RATE_LIMIT_BACKEND
+// ("memory" or "redis") and REDIS_URL environment variables, mirroring
+// session.NewStoreFromEnv so one Redis instance can back both session
+// storage and rate limiting when running multiple replicas behind a load
+// balancer. Falls back to the in-memory limiter (and logs why) on any
+// configuration error, the same way session.NewStore does.
+func NewRateLimiterFromEnv() *RateLimiter {
+	rl := NewRateLimiter()
+
+	switch backend := os.Getenv("RATE_LIMIT_BACKEND"); backend {
+	case "", "memory":
+	case "redis":
+		addr := os.Getenv("REDIS_URL")
+		if addr == "" {
+			addr = "localhost:6379"
+		}
+		b, err := newRedisRateLimitBackend(addr)
+		if err != nil {
+			logging.LogError("Failed to initialize Redis rate limit backend, falling back to memory", err)
+			break
+		}
+		rl.backend = b
+	default:
+		logging.LogError("Unknown RATE_LIMIT_BACKEND, falling back to memory", fmt.Errorf("got %q, want memory or redis", backend))
 	}
+
+	return rl
 }
 
-// GetLimiter returns a rate limiter for the given IP address
+// policyFor returns the named policy, falling back to the package defaults
+// if it hasn't been registered - this keeps a zero-value RateLimiter (as
+// constructed in tests) usable without a policies map.
+func (rl *RateLimiter) policyFor(name string) Policy {
+	if p, ok := rl.policies[name]; ok {
+		return p
+	}
+	if len(rl.policies) > 0 {
+		// Only warn once policies actually exist - a zero-value RateLimiter
+		// (as constructed in tests) has none, and that's expected.
+		logging.LogWarn("Unknown rate limit policy, falling back to default", "policy", name)
+	}
+	return Policy{RatePerMinute: models.RateLimit, Burst: models.RateBurst, TTL: models.RateLimiterTTL}
+}
+
+// GetLimiter returns the "default" policy's rate limiter for the given IP
+// address, creating it on first use.
 func (rl *RateLimiter) GetLimiter(ip string) *rate.Limiter {
+	return rl.limiterFor("default", ip)
+}
+
+// limiterFor returns the rate limiter for ip under the named policy,
+// creating it on first use. Limiters are keyed by policy+IP so the same
+// client can carry independent buckets for, say, uploads and downloads.
+func (rl *RateLimiter) limiterFor(policyName, ip string) *rate.Limiter {
+	key := policyName + ":" + ip
+
 	rl.mutex.Lock()
 	defer rl.mutex.Unlock()
 
-	limiter, exists := rl.limiters[ip]
+	if rl.limiters == nil {
+		rl.limiters = make(map[string]*ipLimiter)
+	}
+
+	entry, exists := rl.limiters[key]
 	if !exists {
-		limiter = rate.NewLimiter(rate.Limit(models.RateLimit)/60, models.RateBurst) // per second rate from per minute
-		rl.limiters[ip] = limiter
+		policy := rl.policyFor(policyName)
+		entry = &ipLimiter{
+			limiter:  rate.NewLimiter(rate.Limit(policy.RatePerMinute)/60, policy.Burst), // per second rate from per minute
+			lastSeen: time.Now(),
+		}
+		rl.limiters[key] = entry
 
 		logging.LogDebug("Created new rate limiter for IP",
 			"ip", ip,
-			"rate_per_minute", models.RateLimit,
-			"burst", models.RateBurst)
+			"policy", policyName,
+			"rate_per_minute", policy.RatePerMinute,
+			"burst", policy.Burst)
+	} else {
+		entry.lastSeen = time.Now()
+	}
+
+	return entry.limiter
+}
+
+// Start runs a janitor goroutine that evicts per-IP limiters idle longer
+// than their policy's TTL, until ctx is canceled. Without this, a scan by
+// many distinct bot IPs would grow the limiter map forever. It is a no-op
+// when a Redis backend is configured, since Redis' own key TTL already
+// reclaims idle counters without a local map to sweep.
+func (rl *RateLimiter) Start(ctx context.Context) {
+	if rl.backend != nil {
+		return
 	}
 
-	return limiter
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				rl.evictIdle()
+			}
+		}
+	}()
 }
 
-// RateLimitMiddleware provides rate limiting functionality
+func (rl *RateLimiter) evictIdle() {
+	now := time.Now()
+
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	for key, entry := range rl.limiters {
+		policyName := key
+		if i := strings.IndexByte(key, ':'); i != -1 {
+			policyName = key[:i]
+		}
+		if now.Sub(entry.lastSeen) > rl.policyFor(policyName).TTL {
+			delete(rl.limiters, key)
+		}
+	}
+}
+
+// RateLimitMiddleware rate-limits next under the "default" policy.
 func (rl *RateLimiter) RateLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return rl.Register("default", next)
+}
+
+// allow reports whether a request from ip is permitted under policyName,
+// and if not, how long the caller should wait before retrying. It
+// delegates to the configured backend when one is set (e.g. Redis),
+// otherwise it consults the in-memory per-IP token bucket directly.
+func (rl *RateLimiter) allow(ctx context.Context, policyName, ip string) (bool, time.Duration) {
+	if rl.backend != nil {
+		return rl.backend.allow(ctx, policyName, ip, rl.policyFor(policyName))
+	}
+
+	limiter := rl.limiterFor(policyName, ip)
+	if limiter.Allow() {
+		return true, 0
+	}
+
+	reservation := limiter.Reserve()
+	retryAfter := reservation.Delay()
+	reservation.Cancel() // we only wanted the delay, not to consume a future token
+	return false, retryAfter
+}
+
+// Close releases any resources held by the configured backend (e.g. the
+// Redis connection). It is a no-op for the in-memory limiter.
+func (rl *RateLimiter) Close() error {
+	if rl.backend == nil {
+		return nil
+	}
+	return rl.backend.close()
+}
+
+// Register wraps next with rate limiting under the named policy, so
+// main.go can apply stricter limits to some routes (e.g. "upload") than
+// others (e.g. "download") while sharing the same RateLimiter.
+func (rl *RateLimiter) Register(policyName string, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ip := GetClientIP(r)
-		limiter := rl.GetLimiter(ip)
+		allowed, retryAfter := rl.allow(r.Context(), policyName, ip)
 
-		if !limiter.Allow() {
+		if !allowed {
 			logging.LogSecurityEvent("Rate limit exceeded", "high",
 				"ip", ip,
+				"policy", policyName,
 				"user_agent", r.UserAgent(),
 				"path", r.URL.Path,
-				"method", r.Method)
+				"method", r.Method,
+				"retry_after_seconds", retryAfter.Seconds())
 
+			metrics.RecordRateLimitRejection(ipClass(ip))
+
+			policy := rl.policyFor(policyName)
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds()+1)))
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(policy.Burst))
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(retryAfter).Unix(), 10))
 			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
 			return
 		}
@@ -65,6 +260,21 @@ func (rl *RateLimiter) RateLimitMiddleware(next http.HandlerFunc) http.HandlerFu
 	}
 }
 
+// ipClass classifies an IP address (as returned by GetClientIP) into the
+// label used by notenschluessel_ratelimit_rejections_total, distinguishing
+// private/loopback clients (internal tooling, health checks) from public
+// ones so dashboards can tell real abuse from internal noise.
+func ipClass(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return "unknown"
+	}
+	if addr.IsLoopback() || addr.IsPrivate() {
+		return "private"
+	}
+	return "public"
+}
+
 // SecurityHeaders adds security headers to HTTP responses
 func SecurityHeaders(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -84,21 +294,75 @@ func SecurityHeaders(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-// GetClientIP extracts the real client IP from request headers
-func GetClientIP(r *http.Request) string {
-	// Check for forwarded IP in common headers
-	forwarded := r.Header.Get("X-Forwarded-For")
-	if forwarded != "" {
-		// X-Forwarded-For can contain multiple IPs, get the first one
-		ips := strings.Split(forwarded, ",")
-		if len(ips) > 0 {
-			return strings.TrimSpace(ips[0])
+// trustedProxies holds the CIDRs GetClientIP trusts to supply an accurate
+// client IP via X-Forwarded-For/X-Real-IP/CF-Connecting-IP. Any other
+// caller could set those headers themselves to spoof their IP and dodge
+// rate limiting. Empty (the default) trusts nothing, so forwarded headers
+// are ignored and RemoteAddr is used as-is, matching a deployment with no
+// reverse proxy in front of it.
+var trustedProxies []netip.Prefix
+
+// SetTrustedProxies configures the CIDRs of the reverse proxies/CDNs
+// GetClientIP trusts to supply forwarded-IP headers. Call this once at
+// startup with the ranges of your load balancer or CDN.
+func SetTrustedProxies(prefixes []netip.Prefix) {
+	trustedProxies = prefixes
+}
+
+// remoteAddrTrusted reports whether remoteAddr (host:port, as found on
+// http.Request.RemoteAddr) falls inside one of trustedProxies. With no
+// trustedProxies configured, nothing is trusted, so GetClientIP falls
+// straight through to RemoteAddr instead of honoring forwarded headers.
+func remoteAddrTrusted(remoteAddr string) bool {
+	if len(trustedProxies) == 0 {
+		return false
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
 		}
 	}
+	return false
+}
 
-	realIP := r.Header.Get("X-Real-IP")
-	if realIP != "" {
-		return realIP
+// GetClientIP extracts the real client IP from request headers. Forwarded
+// headers are only honored when the immediate peer (RemoteAddr) is a
+// trusted proxy; otherwise a client could set CF-Connecting-IP itself to
+// spoof its IP for rate limiting.
+func GetClientIP(r *http.Request) string {
+	if remoteAddrTrusted(r.RemoteAddr) {
+		// Cloudflare sets this itself, overwriting anything the client sent,
+		// so it's the most reliable of the three when present.
+		if cfIP := r.Header.Get("CF-Connecting-IP"); cfIP != "" {
+			return cfIP
+		}
+
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			ips := strings.Split(forwarded, ",")
+			if len(ips) > 0 {
+				// remoteAddrTrusted only returns true once trustedProxies is
+				// configured, so getting here means a trusted proxy appended
+				// the real client IP to the end of this header; anything
+				// before that was supplied by the client (or an untrusted
+				// intermediary) and can't be relied on, so take the
+				// rightmost entry rather than the leftmost.
+				return strings.TrimSpace(ips[len(ips)-1])
+			}
+		}
+
+		if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+			return realIP
+		}
 	}
 
 	// Fallback to remote address
@@ -113,15 +377,22 @@ func GetClientIP(r *http.Request) string {
 	return ip
 }
 
-// ValidateUpload validates file uploads for security
+// ValidateUpload validates a CSV file upload for security.
 func ValidateUpload(fileHeader *multipart.FileHeader) error {
+	return ValidateUploadExt(fileHeader, ".csv")
+}
+
+// ValidateUploadExt validates a file upload for security, accepting any
+// file whose name ends in allowedExt (e.g. ".csv", ".xlsx") instead of
+// being limited to CSV.
+func ValidateUploadExt(fileHeader *multipart.FileHeader, allowedExt string) error {
 	if fileHeader.Size > models.MaxFileSize {
 		return fmt.Errorf("file too large: %d bytes (max: %d)", fileHeader.Size, models.MaxFileSize)
 	}
 
 	filename := fileHeader.Filename
-	if !strings.HasSuffix(strings.ToLower(filename), ".csv") {
-		return fmt.Errorf("only CSV files are allowed")
+	if !strings.HasSuffix(strings.ToLower(filename), allowedExt) {
+		return fmt.Errorf("only %s files are allowed", allowedExt)
 	}
 
 	// Additional filename validation
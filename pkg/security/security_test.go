@@ -3,6 +3,7 @@ package security
 import (
 	"mime/multipart"
 	"net/http"
+	"net/netip"
 	"net/textproto"
 	"testing"
 
@@ -13,9 +14,27 @@ func init() {
 	logging.InitLogger()
 }
 
+// withTrustedProxies configures trustedProxies for the duration of a test,
+// restoring the previous value (the zero value in practice) on cleanup.
+func withTrustedProxies(t *testing.T, prefixes ...string) {
+	t.Helper()
+	parsed := make([]netip.Prefix, len(prefixes))
+	for i, p := range prefixes {
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil {
+			t.Fatalf("invalid test prefix %q: %v", p, err)
+		}
+		parsed[i] = prefix
+	}
+	SetTrustedProxies(parsed)
+	t.Cleanup(func() { SetTrustedProxies(nil) })
+}
+
 // --- GetClientIP ---
 
 func TestGetClientIP_CloudflareHeader(t *testing.T) {
+	withTrustedProxies(t, "9.10.11.12/32")
+
 	r, _ := http.NewRequest("GET", "/", nil)
 	r.Header.Set("CF-Connecting-IP", "1.2.3.4")
 	r.Header.Set("X-Forwarded-For", "5.6.7.8")
@@ -28,17 +47,21 @@ func TestGetClientIP_CloudflareHeader(t *testing.T) {
 }
 
 func TestGetClientIP_XForwardedFor(t *testing.T) {
+	withTrustedProxies(t, "9.10.11.12/32")
+
 	r, _ := http.NewRequest("GET", "/", nil)
 	r.Header.Set("X-Forwarded-For", "10.0.0.1, 10.0.0.2")
 	r.RemoteAddr = "9.10.11.12:1234"
 
 	ip := GetClientIP(r)
-	if ip != "10.0.0.1" {
-		t.Errorf("want first XFF IP 10.0.0.1, got %s", ip)
+	if ip != "10.0.0.2" {
+		t.Errorf("want rightmost (trusted-proxy-appended) XFF IP 10.0.0.2, got %s", ip)
 	}
 }
 
 func TestGetClientIP_XRealIP(t *testing.T) {
+	withTrustedProxies(t, "9.10.11.12/32")
+
 	r, _ := http.NewRequest("GET", "/", nil)
 	r.Header.Set("X-Real-IP", "192.168.1.1")
 	r.RemoteAddr = "9.10.11.12:1234"
@@ -49,6 +72,22 @@ func TestGetClientIP_XRealIP(t *testing.T) {
 	}
 }
 
+func TestGetClientIP_UntrustedProxyIgnoresForwardedHeaders(t *testing.T) {
+	// No TRUSTED_PROXIES configured (the default): forwarded headers from
+	// any peer must be ignored, or a client could spoof them to dodge rate
+	// limiting.
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("CF-Connecting-IP", "1.2.3.4")
+	r.Header.Set("X-Forwarded-For", "5.6.7.8")
+	r.Header.Set("X-Real-IP", "6.7.8.9")
+	r.RemoteAddr = "9.10.11.12:1234"
+
+	ip := GetClientIP(r)
+	if ip != "9.10.11.12" {
+		t.Errorf("want RemoteAddr 9.10.11.12 (forwarded headers ignored), got %s", ip)
+	}
+}
+
 func TestGetClientIP_RemoteAddr(t *testing.T) {
 	r, _ := http.NewRequest("GET", "/", nil)
 	r.RemoteAddr = "172.16.0.1:54321"
@@ -0,0 +1,279 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/payback159/notenschluessel/pkg/logging"
+	"github.com/payback159/notenschluessel/pkg/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+var sessionsBucket = []byte("sessions")
+
+// errStopRange signals early termination of a bucket ForEach from within
+// Range's callback; it never escapes Range itself.
+var errStopRange = errors.New("stop range")
+
+// boltRecord is the value stored per session key, combining the page
+// data with its expiry so a periodic sweep can reap stale entries.
+type boltRecord struct {
+	PageData  models.PageData
+	ExpiresAt time.Time
+}
+
+// BoltStore persists sessions in a local BoltDB file, serialized as JSON.
+// Unlike RedisStore, BoltDB has no native key TTL, so expiry is enforced
+// both on read and by a periodic sweep ticker.
+type BoltStore struct {
+	db       *bolt.DB
+	stopOnce sync.Once
+	stop     chan struct{}
+
+	// ReadTimeout and WriteTimeout bound Get/Set/Delete calls whose
+	// context carries no deadline of its own.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// NewBoltStore opens (creating if necessary) a BoltDB file at path and
+// starts the expiry sweep goroutine.
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(sessionsBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	store := &BoltStore{
+		db:           db,
+		stop:         make(chan struct{}),
+		ReadTimeout:  DefaultReadTimeout,
+		WriteTimeout: DefaultWriteTimeout,
+	}
+	store.startSweep()
+	return store, nil
+}
+
+// Set stores session data with automatic expiration.
+func (s *BoltStore) Set(id string, data models.PageData) {
+	if err := s.SetContext(context.Background(), id, data); err != nil {
+		logging.LogError("Failed to store session in Bolt", err, "session_id", id)
+	}
+}
+
+// Get retrieves session data if it exists and hasn't expired.
+func (s *BoltStore) Get(id string) (models.PageData, bool) {
+	data, found, err := s.GetContext(context.Background(), id)
+	if err != nil {
+		logging.LogError("Failed to read session from Bolt", err, "session_id", id)
+		return models.PageData{}, false
+	}
+	return data, found
+}
+
+// Delete removes a session.
+func (s *BoltStore) Delete(id string) {
+	if err := s.DeleteContext(context.Background(), id); err != nil {
+		logging.LogError("Failed to delete session from Bolt", err, "session_id", id)
+	}
+}
+
+// SetContext stores session data, bounded by ctx and WriteTimeout.
+func (s *BoltStore) SetContext(ctx context.Context, id string, data models.PageData) error {
+	record := boltRecord{
+		PageData:  data,
+		ExpiresAt: time.Now().Add(time.Duration(models.SessionTimeout) * time.Second),
+	}
+
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	var dbErr error
+	if err := withDeadline(ctx, s.WriteTimeout, func() {
+		dbErr = s.db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(sessionsBucket).Put([]byte(id), payload)
+		})
+		if dbErr != nil {
+			return
+		}
+
+		logging.LogDebug("Session data stored in Bolt",
+			"session_id", id,
+			"has_students", data.HasStudents,
+			"student_count", len(data.Students),
+			"expires_at", record.ExpiresAt.Format(time.RFC3339))
+	}); err != nil {
+		return err
+	}
+	return dbErr
+}
+
+// GetContext retrieves session data, bounded by ctx and ReadTimeout.
+func (s *BoltStore) GetContext(ctx context.Context, id string) (models.PageData, bool, error) {
+	var record boltRecord
+	found := false
+
+	err := withDeadline(ctx, s.ReadTimeout, func() {
+		err := s.db.Update(func(tx *bolt.Tx) error {
+			bucket := tx.Bucket(sessionsBucket)
+			payload := bucket.Get([]byte(id))
+			if payload == nil {
+				return nil
+			}
+			if err := json.Unmarshal(payload, &record); err != nil {
+				return err
+			}
+			if time.Now().After(record.ExpiresAt) {
+				return bucket.Delete([]byte(id))
+			}
+			found = true
+			return nil
+		})
+		if err != nil {
+			logging.LogError("Failed to read session from Bolt", err, "session_id", id)
+			return
+		}
+		if !found {
+			logging.LogDebug("Session not found or expired", "session_id", id)
+		}
+	})
+
+	return record.PageData, found, err
+}
+
+// DeleteContext removes a session, bounded by ctx and WriteTimeout.
+func (s *BoltStore) DeleteContext(ctx context.Context, id string) error {
+	return withDeadline(ctx, s.WriteTimeout, func() {
+		if err := s.db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(sessionsBucket).Delete([]byte(id))
+		}); err != nil {
+			logging.LogError("Failed to delete session from Bolt", err, "session_id", id)
+		}
+	})
+}
+
+// Range calls fn for every non-expired session, stopping early if fn
+// returns false.
+func (s *BoltStore) Range(fn func(id string, data *Data) bool) {
+	now := time.Now()
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).ForEach(func(k, v []byte) error {
+			var record boltRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return nil
+			}
+			if now.After(record.ExpiresAt) {
+				return nil
+			}
+			if !fn(string(k), &Data{PageData: record.PageData, ExpiresAt: record.ExpiresAt}) {
+				return errStopRange
+			}
+			return nil
+		})
+	})
+	if err != nil && err != errStopRange {
+		logging.LogError("Failed to range over Bolt sessions", err)
+	}
+}
+
+// GetSessionCount returns the current number of active (non-expired)
+// sessions.
+func (s *BoltStore) GetSessionCount() int {
+	count := 0
+	now := time.Now()
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).ForEach(func(k, v []byte) error {
+			var record boltRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return nil
+			}
+			if now.Before(record.ExpiresAt) {
+				count++
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		logging.LogError("Failed to count sessions in Bolt", err)
+	}
+
+	return count
+}
+
+// Close stops the sweep goroutine and closes the database file.
+func (s *BoltStore) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	return s.db.Close()
+}
+
+// startSweep periodically removes expired sessions from the bucket.
+func (s *BoltStore) startSweep() {
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.sweepExpired()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (s *BoltStore) sweepExpired() {
+	now := time.Now()
+	expiredCount := 0
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(sessionsBucket)
+		var expiredKeys [][]byte
+
+		err := bucket.ForEach(func(k, v []byte) error {
+			var record boltRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return nil
+			}
+			if now.After(record.ExpiresAt) {
+				expiredKeys = append(expiredKeys, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range expiredKeys {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		expiredCount = len(expiredKeys)
+		return nil
+	})
+	if err != nil {
+		logging.LogError("Failed to sweep expired Bolt sessions", err)
+		return
+	}
+
+	if expiredCount > 0 {
+		logging.LogInfo("Cleaned up expired Bolt sessions", "expired_count", expiredCount)
+	}
+}
@@ -0,0 +1,293 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/payback159/notenschluessel/pkg/logging"
+	"github.com/payback159/notenschluessel/pkg/models"
+)
+
+// FileStore persists sessions as a single JSON file on disk, for
+// deployments that want survival across restarts without running a
+// separate database (BoltStore covers the same need with an embedded KV
+// engine instead of a plain JSON file). The whole file is rewritten on every
+// Set/Delete, so it isn't meant for high write volume - use RedisStore or
+// BoltStore for that.
+type FileStore struct {
+	path     string
+	sessions map[string]*Data
+	mutex    sync.RWMutex
+	stopOnce sync.Once
+	stop     chan struct{}
+
+	// ReadTimeout and WriteTimeout bound Get/Set/Delete calls whose
+	// context carries no deadline of its own.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// NewFileStore loads (or creates) the JSON file at path and starts its
+// expiry sweep goroutine.
+func NewFileStore(path string) (*FileStore, error) {
+	store := &FileStore{
+		path:         path,
+		sessions:     make(map[string]*Data),
+		stop:         make(chan struct{}),
+		ReadTimeout:  DefaultReadTimeout,
+		WriteTimeout: DefaultWriteTimeout,
+	}
+
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+
+	store.startSweep()
+	return store, nil
+}
+
+// load reads the session file into memory, treating a missing file as an
+// empty store (the first run on a fresh deployment).
+func (s *FileStore) load() error {
+	payload, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(payload) == 0 {
+		return nil
+	}
+
+	var sessions map[string]*Data
+	if err := json.Unmarshal(payload, &sessions); err != nil {
+		return err
+	}
+	s.sessions = sessions
+	return nil
+}
+
+// persist rewrites the session file with the current in-memory contents.
+// It writes to a temp file in the same directory and renames it over path,
+// so a crash or full disk mid-write leaves the previous, still-valid file
+// in place instead of a truncated one load() can't parse on the next
+// restart. Caller must hold s.mutex.
+func (s *FileStore) persist() error {
+	payload, err := json.Marshal(s.sessions)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once the rename below succeeds
+
+	if _, err := tmp.Write(payload); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, s.path)
+}
+
+// Set stores session data with automatic expiration.
+func (s *FileStore) Set(id string, data models.PageData) {
+	if err := s.SetContext(context.Background(), id, data); err != nil {
+		logging.LogError("Failed to store session in file store", err, "session_id", id)
+	}
+}
+
+// Get retrieves session data if it exists and hasn't expired.
+func (s *FileStore) Get(id string) (models.PageData, bool) {
+	data, found, err := s.GetContext(context.Background(), id)
+	if err != nil {
+		logging.LogError("Failed to read session from file store", err, "session_id", id)
+		return models.PageData{}, false
+	}
+	return data, found
+}
+
+// Delete removes a session.
+func (s *FileStore) Delete(id string) {
+	if err := s.DeleteContext(context.Background(), id); err != nil {
+		logging.LogError("Failed to delete session from file store", err, "session_id", id)
+	}
+}
+
+// SetContext stores session data, bounded by ctx and WriteTimeout.
+func (s *FileStore) SetContext(ctx context.Context, id string, data models.PageData) error {
+	var persistErr error
+	err := withDeadline(ctx, s.WriteTimeout, func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		s.sessions[id] = &Data{
+			PageData:  data,
+			ExpiresAt: time.Now().Add(time.Duration(models.SessionTimeout) * time.Second),
+		}
+
+		if persistErr = s.persist(); persistErr != nil {
+			return
+		}
+
+		logging.LogDebug("Session data stored in file store",
+			"session_id", id,
+			"has_students", data.HasStudents,
+			"student_count", len(data.Students),
+			"expires_at", s.sessions[id].ExpiresAt.Format(time.RFC3339))
+	})
+	if err != nil {
+		return err
+	}
+	return persistErr
+}
+
+// GetContext retrieves session data, bounded by ctx and ReadTimeout.
+func (s *FileStore) GetContext(ctx context.Context, id string) (models.PageData, bool, error) {
+	var pageData models.PageData
+	var found bool
+
+	err := withDeadline(ctx, s.ReadTimeout, func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		sessionData, exists := s.sessions[id]
+		if !exists {
+			logging.LogDebug("Session not found", "session_id", id)
+			return
+		}
+
+		if time.Now().After(sessionData.ExpiresAt) {
+			logging.LogDebug("Session expired",
+				"session_id", id,
+				"expired_at", sessionData.ExpiresAt.Format(time.RFC3339))
+			delete(s.sessions, id)
+			if err := s.persist(); err != nil {
+				logging.LogError("Failed to persist file store after expiry removal", err, "session_id", id)
+			}
+			return
+		}
+
+		pageData = sessionData.PageData
+		found = true
+	})
+
+	return pageData, found, err
+}
+
+// DeleteContext removes a session, bounded by ctx and WriteTimeout.
+func (s *FileStore) DeleteContext(ctx context.Context, id string) error {
+	var persistErr error
+	err := withDeadline(ctx, s.WriteTimeout, func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if _, exists := s.sessions[id]; !exists {
+			return
+		}
+		delete(s.sessions, id)
+		persistErr = s.persist()
+	})
+	if err != nil {
+		return err
+	}
+	return persistErr
+}
+
+// Range calls fn for every non-expired session, stopping early if fn
+// returns false.
+func (s *FileStore) Range(fn func(id string, data *Data) bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := time.Now()
+	for id, data := range s.sessions {
+		if now.After(data.ExpiresAt) {
+			continue
+		}
+		if !fn(id, data) {
+			return
+		}
+	}
+}
+
+// GetSessionCount returns the current number of active (non-expired)
+// sessions.
+func (s *FileStore) GetSessionCount() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	count := 0
+	now := time.Now()
+	for _, data := range s.sessions {
+		if now.Before(data.ExpiresAt) {
+			count++
+		}
+	}
+	return count
+}
+
+// Close stops the sweep goroutine.
+func (s *FileStore) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	return nil
+}
+
+// startSweep periodically removes expired sessions and rewrites the file.
+func (s *FileStore) startSweep() {
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.sweepExpired()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (s *FileStore) sweepExpired() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	expiredCount := 0
+
+	for id, data := range s.sessions {
+		if now.After(data.ExpiresAt) {
+			delete(s.sessions, id)
+			expiredCount++
+		}
+	}
+
+	if expiredCount == 0 {
+		return
+	}
+
+	if err := s.persist(); err != nil {
+		logging.LogError("Failed to persist file store after sweep", err)
+		return
+	}
+
+	logging.LogInfo("Cleaned up expired file store sessions",
+		"expired_count", expiredCount,
+		"remaining_sessions", len(s.sessions))
+}
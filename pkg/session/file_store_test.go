@@ -0,0 +1,119 @@
+package session
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/payback159/notenschluessel/pkg/models"
+)
+
+func newTestFileStore(t *testing.T) *FileStore {
+	t.Helper()
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestFileStore_SetAndGet(t *testing.T) {
+	store := newTestFileStore(t)
+
+	store.Set("test-id", models.PageData{MaxPoints: 100, HasResults: true})
+
+	got, ok := store.Get("test-id")
+	if !ok {
+		t.Fatal("expected session to exist")
+	}
+	if got.MaxPoints != 100 {
+		t.Errorf("MaxPoints: want 100, got %d", got.MaxPoints)
+	}
+}
+
+func TestFileStore_GetNonExistent(t *testing.T) {
+	store := newTestFileStore(t)
+
+	if _, ok := store.Get("does-not-exist"); ok {
+		t.Error("expected false for non-existent session")
+	}
+}
+
+func TestFileStore_Delete(t *testing.T) {
+	store := newTestFileStore(t)
+	store.Set("del-me", models.PageData{MaxPoints: 5})
+
+	store.Delete("del-me")
+
+	if _, ok := store.Get("del-me"); ok {
+		t.Error("session should have been deleted")
+	}
+}
+
+func TestFileStore_GetExpired(t *testing.T) {
+	store := newTestFileStore(t)
+
+	store.mutex.Lock()
+	store.sessions["expired"] = &Data{
+		PageData:  models.PageData{MaxPoints: 50},
+		ExpiresAt: time.Now().Add(-1 * time.Hour),
+	}
+	store.mutex.Unlock()
+
+	if _, ok := store.Get("expired"); ok {
+		t.Error("expected false for expired session")
+	}
+}
+
+func TestFileStore_SurvivesReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	store.Set("persisted", models.PageData{MaxPoints: 77})
+	store.Close()
+
+	reloaded, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore (reload): %v", err)
+	}
+	defer reloaded.Close()
+
+	got, ok := reloaded.Get("persisted")
+	if !ok {
+		t.Fatal("expected session to survive reload from disk")
+	}
+	if got.MaxPoints != 77 {
+		t.Errorf("MaxPoints: want 77, got %d", got.MaxPoints)
+	}
+}
+
+func TestFileStore_GetSessionCount(t *testing.T) {
+	store := newTestFileStore(t)
+
+	if store.GetSessionCount() != 0 {
+		t.Error("expected 0 sessions initially")
+	}
+
+	store.Set("a", models.PageData{})
+	store.Set("b", models.PageData{})
+
+	if store.GetSessionCount() != 2 {
+		t.Errorf("expected 2 sessions, got %d", store.GetSessionCount())
+	}
+}
+
+func TestFileStore_MissingFileStartsEmpty(t *testing.T) {
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "does-not-exist-yet.json"))
+	if err != nil {
+		t.Fatalf("NewFileStore should treat a missing file as an empty store: %v", err)
+	}
+	defer store.Close()
+
+	if store.GetSessionCount() != 0 {
+		t.Errorf("expected 0 sessions, got %d", store.GetSessionCount())
+	}
+}
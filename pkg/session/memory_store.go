@@ -0,0 +1,197 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/payback159/notenschluessel/pkg/logging"
+	"github.com/payback159/notenschluessel/pkg/models"
+)
+
+// MemoryStore is the default Store implementation: sessions live in a
+// map guarded by a mutex and are lost on process restart.
+type MemoryStore struct {
+	sessions map[string]*Data
+	mutex    sync.RWMutex
+	stopOnce sync.Once
+	stop     chan struct{}
+
+	// ReadTimeout and WriteTimeout bound Get/Set/Delete calls whose
+	// context carries no deadline of its own.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// NewMemoryStore creates a new in-memory session store and starts its
+// cleanup routine.
+func NewMemoryStore() *MemoryStore {
+	store := &MemoryStore{
+		sessions:     make(map[string]*Data),
+		stop:         make(chan struct{}),
+		ReadTimeout:  DefaultReadTimeout,
+		WriteTimeout: DefaultWriteTimeout,
+	}
+	store.startCleanup()
+	return store
+}
+
+// Set stores session data with automatic expiration.
+func (s *MemoryStore) Set(id string, data models.PageData) {
+	if err := s.SetContext(context.Background(), id, data); err != nil {
+		logging.LogError("Failed to store session", err, "session_id", id)
+	}
+}
+
+// Get retrieves session data if it exists and hasn't expired.
+func (s *MemoryStore) Get(id string) (models.PageData, bool) {
+	data, found, err := s.GetContext(context.Background(), id)
+	if err != nil {
+		logging.LogError("Failed to retrieve session", err, "session_id", id)
+		return models.PageData{}, false
+	}
+	return data, found
+}
+
+// Delete removes a session.
+func (s *MemoryStore) Delete(id string) {
+	if err := s.DeleteContext(context.Background(), id); err != nil {
+		logging.LogError("Failed to delete session", err, "session_id", id)
+	}
+}
+
+// SetContext stores session data, bounded by ctx and WriteTimeout.
+func (s *MemoryStore) SetContext(ctx context.Context, id string, data models.PageData) error {
+	return withDeadline(ctx, s.WriteTimeout, func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		s.sessions[id] = &Data{
+			PageData:  data,
+			ExpiresAt: time.Now().Add(time.Duration(models.SessionTimeout) * time.Second),
+		}
+
+		logging.LogDebug("Session data stored",
+			"session_id", id,
+			"has_students", data.HasStudents,
+			"student_count", len(data.Students),
+			"expires_at", s.sessions[id].ExpiresAt.Format(time.RFC3339))
+	})
+}
+
+// GetContext retrieves session data, bounded by ctx and ReadTimeout.
+func (s *MemoryStore) GetContext(ctx context.Context, id string) (models.PageData, bool, error) {
+	var pageData models.PageData
+	var found bool
+
+	err := withDeadline(ctx, s.ReadTimeout, func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		sessionData, exists := s.sessions[id]
+		if !exists {
+			logging.LogDebug("Session not found", "session_id", id)
+			return
+		}
+
+		if time.Now().After(sessionData.ExpiresAt) {
+			logging.LogDebug("Session expired",
+				"session_id", id,
+				"expired_at", sessionData.ExpiresAt.Format(time.RFC3339))
+			delete(s.sessions, id)
+			return
+		}
+
+		logging.LogDebug("Session retrieved successfully",
+			"session_id", id,
+			"has_students", sessionData.PageData.HasStudents,
+			"student_count", len(sessionData.PageData.Students))
+
+		pageData = sessionData.PageData
+		found = true
+	})
+
+	return pageData, found, err
+}
+
+// DeleteContext removes a session, bounded by ctx and WriteTimeout.
+func (s *MemoryStore) DeleteContext(ctx context.Context, id string) error {
+	return withDeadline(ctx, s.WriteTimeout, func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if _, exists := s.sessions[id]; exists {
+			delete(s.sessions, id)
+			logging.LogDebug("Session deleted", "session_id", id)
+		}
+	})
+}
+
+// Range calls fn for every non-expired session, stopping early if fn
+// returns false.
+func (s *MemoryStore) Range(fn func(id string, data *Data) bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := time.Now()
+	for id, data := range s.sessions {
+		if now.After(data.ExpiresAt) {
+			continue
+		}
+		if !fn(id, data) {
+			return
+		}
+	}
+}
+
+// GetSessionCount returns the current number of active sessions.
+func (s *MemoryStore) GetSessionCount() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.sessions)
+}
+
+// Close stops the background cleanup goroutine.
+func (s *MemoryStore) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	return nil
+}
+
+// startCleanup runs a background goroutine to clean up expired sessions.
+func (s *MemoryStore) startCleanup() {
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.cleanupExpired()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// cleanupExpired removes all expired sessions.
+func (s *MemoryStore) cleanupExpired() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	expiredCount := 0
+
+	for id, sessionData := range s.sessions {
+		if now.After(sessionData.ExpiresAt) {
+			delete(s.sessions, id)
+			expiredCount++
+		}
+	}
+
+	if expiredCount > 0 {
+		logging.LogInfo("Cleaned up expired sessions",
+			"expired_count", expiredCount,
+			"remaining_sessions", len(s.sessions))
+	}
+}
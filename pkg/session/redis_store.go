@@ -0,0 +1,204 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/payback159/notenschluessel/pkg/logging"
+	"github.com/payback159/notenschluessel/pkg/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore persists sessions in Redis, serialized as JSON, so state
+// survives restarts and can be shared across replicas. Expiry is enforced
+// by Redis' own key TTL, so no separate sweep goroutine is needed.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+
+	// ReadTimeout and WriteTimeout bound Get/Set/Delete calls whose
+	// context carries no deadline of its own.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// RedisConfig configures a RedisStore.
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+	// Prefix namespaces session keys, default "notenschluessel:session:".
+	Prefix string
+}
+
+// NewRedisStore creates a RedisStore and verifies connectivity with a PING.
+func NewRedisStore(cfg RedisConfig) (*RedisStore, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+
+	prefix := cfg.Prefix
+	if prefix == "" {
+		prefix = "notenschluessel:session:"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, err
+	}
+
+	return &RedisStore{
+		client:       client,
+		prefix:       prefix,
+		ReadTimeout:  DefaultReadTimeout,
+		WriteTimeout: DefaultWriteTimeout,
+	}, nil
+}
+
+func (s *RedisStore) key(id string) string {
+	return s.prefix + id
+}
+
+// withTimeout returns ctx unchanged if it already carries a deadline,
+// otherwise wraps it with timeout so the Redis client call it guards
+// can't block indefinitely.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, hasDeadline := ctx.Deadline(); hasDeadline {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Set stores session data with automatic expiration via the Redis key TTL.
+func (s *RedisStore) Set(id string, data models.PageData) {
+	if err := s.SetContext(context.Background(), id, data); err != nil {
+		logging.LogError("Failed to store session in Redis", err, "session_id", id)
+	}
+}
+
+// Get retrieves session data if it exists and hasn't expired.
+func (s *RedisStore) Get(id string) (models.PageData, bool) {
+	data, found, err := s.GetContext(context.Background(), id)
+	if err != nil {
+		logging.LogError("Failed to read session from Redis", err, "session_id", id)
+		return models.PageData{}, false
+	}
+	return data, found
+}
+
+// Delete removes a session.
+func (s *RedisStore) Delete(id string) {
+	if err := s.DeleteContext(context.Background(), id); err != nil {
+		logging.LogError("Failed to delete session from Redis", err, "session_id", id)
+	}
+}
+
+// SetContext stores session data, bounded by ctx and WriteTimeout.
+func (s *RedisStore) SetContext(ctx context.Context, id string, data models.PageData) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logging.LogError("Failed to marshal session data for Redis", err, "session_id", id)
+		return err
+	}
+
+	ctx, cancel := withTimeout(ctx, s.WriteTimeout)
+	defer cancel()
+
+	ttl := time.Duration(models.SessionTimeout) * time.Second
+	if err := s.client.Set(ctx, s.key(id), payload, ttl).Err(); err != nil {
+		return err
+	}
+
+	logging.LogDebug("Session data stored in Redis",
+		"session_id", id,
+		"has_students", data.HasStudents,
+		"student_count", len(data.Students))
+	return nil
+}
+
+// GetContext retrieves session data, bounded by ctx and ReadTimeout.
+func (s *RedisStore) GetContext(ctx context.Context, id string) (models.PageData, bool, error) {
+	ctx, cancel := withTimeout(ctx, s.ReadTimeout)
+	defer cancel()
+
+	payload, err := s.client.Get(ctx, s.key(id)).Bytes()
+	if err == redis.Nil {
+		logging.LogDebug("Session not found", "session_id", id)
+		return models.PageData{}, false, nil
+	}
+	if err != nil {
+		return models.PageData{}, false, err
+	}
+
+	var data models.PageData
+	if err := json.Unmarshal(payload, &data); err != nil {
+		logging.LogError("Failed to unmarshal session data from Redis", err, "session_id", id)
+		return models.PageData{}, false, nil
+	}
+
+	return data, true, nil
+}
+
+// DeleteContext removes a session, bounded by ctx and WriteTimeout.
+func (s *RedisStore) DeleteContext(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx, s.WriteTimeout)
+	defer cancel()
+	return s.client.Del(ctx, s.key(id)).Err()
+}
+
+// Range calls fn for every non-expired session, stopping early if fn
+// returns false. Each session is fetched individually after the key scan,
+// so it may race with concurrent expiry or deletion.
+func (s *RedisStore) Range(fn func(id string, data *Data) bool) {
+	ctx := context.Background()
+	iter := s.client.Scan(ctx, 0, s.prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		id := strings.TrimPrefix(key, s.prefix)
+
+		payload, err := s.client.Get(ctx, key).Bytes()
+		if err != nil {
+			continue
+		}
+		var pageData models.PageData
+		if err := json.Unmarshal(payload, &pageData); err != nil {
+			continue
+		}
+		ttl, err := s.client.TTL(ctx, key).Result()
+		expiresAt := time.Now()
+		if err == nil && ttl > 0 {
+			expiresAt = time.Now().Add(ttl)
+		}
+
+		if !fn(id, &Data{PageData: pageData, ExpiresAt: expiresAt}) {
+			return
+		}
+	}
+	if err := iter.Err(); err != nil {
+		logging.LogError("Failed to scan sessions in Redis", err)
+	}
+}
+
+// GetSessionCount returns the current number of active sessions by
+// scanning keys under the configured prefix.
+func (s *RedisStore) GetSessionCount() int {
+	var count int
+	iter := s.client.Scan(context.Background(), 0, s.prefix+"*", 100).Iterator()
+	for iter.Next(context.Background()) {
+		count++
+	}
+	if err := iter.Err(); err != nil {
+		logging.LogError("Failed to count sessions in Redis", err)
+	}
+	return count
+}
+
+// Close closes the underlying Redis client connection.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -1,128 +1,126 @@
 package session
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
-	"sync"
+	"fmt"
+	"os"
 	"time"
 
 	"github.com/payback159/notenschluessel/pkg/logging"
 	"github.com/payback159/notenschluessel/pkg/models"
 )
 
-// Store manages user sessions with automatic cleanup
-type Store struct {
-	sessions map[string]*Data
-	mutex    sync.RWMutex
-}
+// DefaultReadTimeout and DefaultWriteTimeout bound how long a Get/Set/Delete
+// call may wait when the caller's context carries no deadline of its own.
+const (
+	DefaultReadTimeout  = 2 * time.Second
+	DefaultWriteTimeout = 2 * time.Second
+)
 
-// Data holds session information with expiration
-type Data struct {
-	PageData  models.PageData
-	ExpiresAt time.Time
+// Store abstracts session persistence so the backend (in-memory, Redis,
+// BoltDB, ...) can be swapped without touching callers.
+type Store interface {
+	// Set stores session data with automatic expiration.
+	Set(id string, data models.PageData)
+	// Get retrieves session data if it exists and hasn't expired.
+	Get(id string) (models.PageData, bool)
+	// Delete removes a session.
+	Delete(id string)
+	// SetContext is like Set but returns context.Canceled or
+	// context.DeadlineExceeded if ctx fires (or the store's configured
+	// write deadline elapses) before the operation completes.
+	SetContext(ctx context.Context, id string, data models.PageData) error
+	// GetContext is like Get but honors ctx/the store's read deadline.
+	GetContext(ctx context.Context, id string) (models.PageData, bool, error)
+	// DeleteContext is like Delete but honors ctx/the store's write deadline.
+	DeleteContext(ctx context.Context, id string) error
+	// Range calls fn for every non-expired session, stopping early if fn
+	// returns false. Iteration order is unspecified.
+	Range(fn func(id string, data *Data) bool)
+	// GetSessionCount returns the current number of active sessions.
+	GetSessionCount() int
+	// Close releases any resources (connections, background goroutines)
+	// held by the store.
+	Close() error
 }
 
-// NewStore creates a new session store and starts cleanup routine
-func NewStore() *Store {
-	store := &Store{
-		sessions: make(map[string]*Data),
+// withDeadline runs op on its own goroutine and bounds how long the caller
+// waits for it: if ctx carries no deadline, timeout is applied as one. It
+// returns ctx.Err() if the context fires first, leaving op to finish (and
+// its side effects to land) in the background.
+func withDeadline(ctx context.Context, timeout time.Duration, op func()) error {
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline && timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
-	store.startCleanup()
-	return store
-}
 
-// Set stores session data with automatic expiration
-func (s *Store) Set(id string, data models.PageData) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+	done := make(chan struct{})
+	go func() {
+		op()
+		close(done)
+	}()
 
-	s.sessions[id] = &Data{
-		PageData:  data,
-		ExpiresAt: time.Now().Add(time.Duration(models.SessionTimeout) * time.Second),
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-
-	logging.LogDebug("Session data stored",
-		"session_id", id,
-		"has_students", data.HasStudents,
-		"student_count", len(data.Students),
-		"expires_at", s.sessions[id].ExpiresAt.Format(time.RFC3339))
 }
 
-// Get retrieves session data if it exists and hasn't expired
-func (s *Store) Get(id string) (models.PageData, bool) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
-	sessionData, exists := s.sessions[id]
-	if !exists {
-		logging.LogDebug("Session not found", "session_id", id)
-		return models.PageData{}, false
-	}
-
-	if time.Now().After(sessionData.ExpiresAt) {
-		logging.LogDebug("Session expired",
-			"session_id", id,
-			"expired_at", sessionData.ExpiresAt.Format(time.RFC3339))
-
-		delete(s.sessions, id)
-
-		return models.PageData{}, false
-	}
-
-	logging.LogDebug("Session retrieved successfully",
-		"session_id", id,
-		"has_students", sessionData.PageData.HasStudents,
-		"student_count", len(sessionData.PageData.Students))
-
-	return sessionData.PageData, true
+// Data holds session information with expiration.
+type Data struct {
+	PageData  models.PageData
+	ExpiresAt time.Time
 }
 
-// Delete removes a session
-func (s *Store) Delete(id string) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
-	if _, exists := s.sessions[id]; exists {
-		delete(s.sessions, id)
-		logging.LogDebug("Session deleted", "session_id", id)
+// NewStore creates a Store backed by the backend named in the
+// SESSION_BACKEND environment variable ("memory", "redis", "bolt" or
+// "file"), defaulting to the in-memory implementation.
+func NewStore() Store {
+	store, err := NewStoreFromEnv()
+	if err != nil {
+		logging.LogError("Failed to initialize configured session backend, falling back to memory", err)
+		return NewMemoryStore()
 	}
+	return store
 }
 
-// startCleanup runs a background goroutine to clean up expired sessions
-func (s *Store) startCleanup() {
-	go func() {
-		ticker := time.NewTicker(5 * time.Minute)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			s.cleanupExpired()
+// NewStoreFromEnv builds a Store from SESSION_BACKEND/REDIS_URL/BOLT_PATH/
+// FILE_STORE_PATH environment variables, returning an error instead of
+// silently falling back so callers can decide how to handle a
+// misconfiguration.
+func NewStoreFromEnv() (Store, error) {
+	switch backend := os.Getenv("SESSION_BACKEND"); backend {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "redis":
+		addr := os.Getenv("REDIS_URL")
+		if addr == "" {
+			addr = "localhost:6379"
 		}
-	}()
-}
-
-// cleanupExpired removes all expired sessions
-func (s *Store) cleanupExpired() {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
-	now := time.Now()
-	expiredCount := 0
-
-	for id, sessionData := range s.sessions {
-		if now.After(sessionData.ExpiresAt) {
-			delete(s.sessions, id)
-			expiredCount++
+		return NewRedisStore(RedisConfig{Addr: addr})
+	case "bolt":
+		path := os.Getenv("BOLT_PATH")
+		if path == "" {
+			path = "sessions.db"
 		}
-	}
-
-	if expiredCount > 0 {
-		logging.LogInfo("Cleaned up expired sessions",
-			"expired_count", expiredCount,
-			"remaining_sessions", len(s.sessions))
+		return NewBoltStore(path)
+	case "file":
+		path := os.Getenv("FILE_STORE_PATH")
+		if path == "" {
+			path = "sessions.json"
+		}
+		return NewFileStore(path)
+	default:
+		return nil, fmt.Errorf("unknown SESSION_BACKEND %q (want memory, redis, bolt or file)", backend)
 	}
 }
 
-// GenerateSessionID creates a cryptographically secure random session ID
+// GenerateSessionID creates a cryptographically secure random session ID.
 func GenerateSessionID() (string, error) {
 	bytes := make([]byte, 16)
 	if _, err := rand.Read(bytes); err != nil {
@@ -131,10 +129,3 @@ func GenerateSessionID() (string, error) {
 	}
 	return hex.EncodeToString(bytes), nil
 }
-
-// GetSessionCount returns the current number of active sessions
-func (s *Store) GetSessionCount() int {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
-	return len(s.sessions)
-}
@@ -42,7 +42,7 @@ func TestGenerateSessionID_HexEncoded(t *testing.T) {
 // --- Store Set/Get ---
 
 func TestStore_SetAndGet(t *testing.T) {
-	store := &Store{sessions: make(map[string]*Data)}
+	store := NewMemoryStore()
 
 	data := models.PageData{
 		MaxPoints:  100,
@@ -63,7 +63,7 @@ func TestStore_SetAndGet(t *testing.T) {
 }
 
 func TestStore_GetNonExistent(t *testing.T) {
-	store := &Store{sessions: make(map[string]*Data)}
+	store := NewMemoryStore()
 
 	_, ok := store.Get("does-not-exist")
 	if ok {
@@ -72,7 +72,7 @@ func TestStore_GetNonExistent(t *testing.T) {
 }
 
 func TestStore_GetExpired(t *testing.T) {
-	store := &Store{sessions: make(map[string]*Data)}
+	store := NewMemoryStore()
 
 	// Manually insert an expired session
 	store.sessions["expired"] = &Data{
@@ -95,7 +95,7 @@ func TestStore_GetExpired(t *testing.T) {
 }
 
 func TestStore_OverwriteSession(t *testing.T) {
-	store := &Store{sessions: make(map[string]*Data)}
+	store := NewMemoryStore()
 
 	store.Set("id1", models.PageData{MaxPoints: 10})
 	store.Set("id1", models.PageData{MaxPoints: 99})
@@ -112,7 +112,7 @@ func TestStore_OverwriteSession(t *testing.T) {
 // --- Delete ---
 
 func TestStore_Delete(t *testing.T) {
-	store := &Store{sessions: make(map[string]*Data)}
+	store := NewMemoryStore()
 	store.Set("del-me", models.PageData{MaxPoints: 5})
 
 	store.Delete("del-me")
@@ -124,7 +124,7 @@ func TestStore_Delete(t *testing.T) {
 }
 
 func TestStore_DeleteNonExistent(t *testing.T) {
-	store := &Store{sessions: make(map[string]*Data)}
+	store := NewMemoryStore()
 	// Should not panic
 	store.Delete("nope")
 }
@@ -132,7 +132,7 @@ func TestStore_DeleteNonExistent(t *testing.T) {
 // --- GetSessionCount ---
 
 func TestStore_GetSessionCount(t *testing.T) {
-	store := &Store{sessions: make(map[string]*Data)}
+	store := NewMemoryStore()
 
 	if store.GetSessionCount() != 0 {
 		t.Error("expected 0 sessions initially")
@@ -149,7 +149,7 @@ func TestStore_GetSessionCount(t *testing.T) {
 // --- cleanupExpired ---
 
 func TestStore_CleanupExpired(t *testing.T) {
-	store := &Store{sessions: make(map[string]*Data)}
+	store := NewMemoryStore()
 
 	// Add one valid and one expired session
 	store.sessions["valid"] = &Data{
@@ -178,7 +178,7 @@ func TestStore_CleanupExpired(t *testing.T) {
 // --- Concurrency ---
 
 func TestStore_ConcurrentAccess(t *testing.T) {
-	store := &Store{sessions: make(map[string]*Data)}
+	store := NewMemoryStore()
 
 	var wg sync.WaitGroup
 	// Run many concurrent Set/Get/Delete operations
@@ -204,7 +204,7 @@ func TestStore_ConcurrentAccess(t *testing.T) {
 }
 
 func TestStore_SessionDataWithStudents(t *testing.T) {
-	store := &Store{sessions: make(map[string]*Data)}
+	store := NewMemoryStore()
 
 	data := models.PageData{
 		MaxPoints:   100,
@@ -0,0 +1,375 @@
+package uploads
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/payback159/notenschluessel/pkg/logging"
+	"github.com/payback159/notenschluessel/pkg/models"
+	"github.com/payback159/notenschluessel/pkg/security"
+	"github.com/payback159/notenschluessel/pkg/session"
+	"github.com/xuri/excelize/v2"
+)
+
+// ImportError pinpoints a single row/column that failed validation, so the
+// frontend can highlight the offending cell instead of just reporting a
+// row count.
+type ImportError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column"`
+	Message string `json:"message"`
+}
+
+// ImportResponse reports the outcome of a roster upload.
+type ImportResponse struct {
+	Imported int           `json:"imported"`
+	Skipped  int           `json:"skipped"`
+	Errors   []ImportError `json:"errors,omitempty"`
+}
+
+// validateImportUpload checks size, extension and filename safety, mirroring
+// security.ValidateUpload but parameterized by extension since it is shared
+// between the CSV and Excel roster import handlers.
+func validateImportUpload(fileHeader *multipart.FileHeader, allowedExt string) error {
+	if fileHeader.Size > models.MaxFileSize {
+		return fmt.Errorf("file too large: %d bytes (max: %d)", fileHeader.Size, models.MaxFileSize)
+	}
+
+	filename := fileHeader.Filename
+	if !strings.HasSuffix(strings.ToLower(filename), allowedExt) {
+		return fmt.Errorf("only %s files are allowed", allowedExt)
+	}
+	if len(filename) > models.MaxNameLength {
+		return fmt.Errorf("filename too long (max: %d characters)", models.MaxNameLength)
+	}
+
+	dangerousChars := []string{"../", "..\\", "<", ">", "|", "&", ";", "$", "`"}
+	for _, char := range dangerousChars {
+		if strings.Contains(filename, char) {
+			return fmt.Errorf("filename contains invalid characters")
+		}
+	}
+
+	return nil
+}
+
+// readCSVRows reads the whole upload and parses it as CSV, auto-detecting
+// the delimiter the same way calculator.ParseCSVFile does: semicolon wins
+// over comma when it appears more often in the file.
+func readCSVRows(file multipart.File) ([][]string, error) {
+	data, err := io.ReadAll(io.LimitReader(file, models.MaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("could not read file: %w", err)
+	}
+
+	delimiter := ','
+	if strings.Count(string(data), ";") > strings.Count(string(data), ",") {
+		delimiter = ';'
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// isHeaderRow reports whether row 0 of a roster is a "Name"/"Punkte" header
+// rather than data, so it can be skipped instead of rejected as invalid.
+func isHeaderRow(i int, record []string) bool {
+	return i == 0 && len(record) > 0 && strings.EqualFold(strings.TrimSpace(record[0]), "name")
+}
+
+// detectDecimalComma inspects the first data row's points column: if it
+// contains a comma but no dot, the file uses the German decimal comma
+// convention and point values need "," replaced with "." before parsing.
+func detectDecimalComma(rows [][]string) bool {
+	for i, record := range rows {
+		if isHeaderRow(i, record) || len(record) < 2 {
+			continue
+		}
+		val := strings.TrimSpace(record[1])
+		if val == "" {
+			continue
+		}
+		return strings.Contains(val, ",") && !strings.Contains(val, ".")
+	}
+	return false
+}
+
+// parseRosterRows validates a Name+Punkte roster already split into rows
+// (from either encoding/csv or excelize's GetRows) and returns the students
+// that passed validation alongside a structured error per row that didn't,
+// so the caller can report both partial success and exact failure
+// locations. maxPoints/hasMaxPoints reject rows scoring above the session's
+// configured maximum, when one has already been set.
+func parseRosterRows(rows [][]string, maxPoints float64, hasMaxPoints bool) ([]models.Student, []ImportError) {
+	var students []models.Student
+	var errs []ImportError
+	seen := make(map[string]bool)
+	decimalComma := detectDecimalComma(rows)
+
+	for i, record := range rows {
+		rowNum := i + 1 // 1-based, matching spreadsheet/CSV row numbers
+
+		if isHeaderRow(i, record) {
+			continue
+		}
+		if len(record) == 0 || (strings.TrimSpace(record[0]) == "" && (len(record) < 2 || strings.TrimSpace(record[1]) == "")) {
+			continue // blank row
+		}
+		if len(record) < 2 {
+			errs = append(errs, ImportError{Row: rowNum, Column: "points", Message: "Punktwert fehlt"})
+			continue
+		}
+
+		name := strings.TrimSpace(record[0])
+		pointsStr := strings.TrimSpace(record[1])
+
+		if name == "" {
+			errs = append(errs, ImportError{Row: rowNum, Column: "name", Message: "Name fehlt"})
+			continue
+		}
+		if len(name) > models.MaxNameLength {
+			errs = append(errs, ImportError{Row: rowNum, Column: "name", Message: fmt.Sprintf("Name überschreitet %d Zeichen", models.MaxNameLength)})
+			continue
+		}
+
+		key := strings.ToLower(name)
+		if seen[key] {
+			errs = append(errs, ImportError{Row: rowNum, Column: "name", Message: fmt.Sprintf("doppelter Name %q", name)})
+			continue
+		}
+
+		if decimalComma {
+			pointsStr = strings.ReplaceAll(pointsStr, ",", ".")
+		}
+		points, err := strconv.ParseFloat(pointsStr, 64)
+		if err != nil {
+			errs = append(errs, ImportError{Row: rowNum, Column: "points", Message: fmt.Sprintf("ungültiger Punktwert %q", record[1])})
+			continue
+		}
+		if points < 0 || points > 1000 {
+			errs = append(errs, ImportError{Row: rowNum, Column: "points", Message: "Punktwert außerhalb des gültigen Bereichs (0-1000)"})
+			continue
+		}
+		if hasMaxPoints && points > maxPoints {
+			errs = append(errs, ImportError{Row: rowNum, Column: "points", Message: fmt.Sprintf("Punktwert überschreitet Maximalpunktzahl (%.1f)", maxPoints)})
+			continue
+		}
+
+		seen[key] = true
+		students = append(students, models.Student{Name: security.SanitizeName(name), Points: points})
+	}
+
+	return students, errs
+}
+
+// appendRosterStudents adds validated students to the session's roster,
+// stopping and recording an error once MaxStudents is reached, the same
+// limit HandleStudentsBatchImport enforces.
+func appendRosterStudents(pageData *models.PageData, students []models.Student, errs []ImportError) ([]ImportError, int) {
+	imported := 0
+	for _, student := range students {
+		if len(pageData.Students) >= models.MaxStudents {
+			errs = append(errs, ImportError{Message: fmt.Sprintf("maximale Schülerzahl (%d) erreicht", models.MaxStudents)})
+			break
+		}
+		pageData.Students = append(pageData.Students, student)
+		imported++
+	}
+	return errs, imported
+}
+
+// HandleStudentImportCSV accepts a multipart "file" upload of a Name+Punkte
+// roster (German-locale semicolon/comma-decimal CSVs auto-detected from the
+// data), validates it, appends the resulting students to the session, and
+// recalculates grades if grade bounds already exist.
+func HandleStudentImportCSV(w http.ResponseWriter, r *http.Request, sessionStore session.Store) {
+	start := time.Now()
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := getSessionIDFromCookie(r)
+	ip := security.GetClientIP(r)
+	if sessionID == "" {
+		http.Error(w, "Keine Session gefunden", http.StatusBadRequest)
+		return
+	}
+
+	pageData, _, err := sessionStore.GetContext(r.Context(), sessionID)
+	if err != nil {
+		logging.LogWarn("Session lookup aborted", "session_id", sessionID, "ip", ip, "error", err.Error())
+		http.Error(w, "Sitzungszugriff fehlgeschlagen", http.StatusBadGateway)
+		return
+	}
+
+	if err := r.ParseMultipartForm(models.MaxFileSize); err != nil {
+		http.Error(w, "Ungültige Upload-Anfrage", http.StatusBadRequest)
+		return
+	}
+
+	file, fileHeader, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Keine Datei hochgeladen", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if err := validateImportUpload(fileHeader, ".csv"); err != nil {
+		logging.LogSecurityEvent("Invalid roster upload attempted", "medium",
+			"filename", fileHeader.Filename,
+			"size", fileHeader.Size,
+			"error", err.Error())
+		http.Error(w, fmt.Sprintf("Ungültige Datei: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := readCSVRows(file)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("CSV konnte nicht gelesen werden: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	hasMaxPoints := pageData.HasResults
+	var maxPoints float64
+	if hasMaxPoints {
+		maxPoints = float64(pageData.MaxPoints)
+	}
+
+	students, importErrors := parseRosterRows(rows, maxPoints, hasMaxPoints)
+	importErrors, imported := appendRosterStudents(&pageData, students, importErrors)
+	recordUploadOutcomes(imported, len(importErrors))
+
+	recalculate(&pageData)
+
+	if err := sessionStore.SetContext(r.Context(), sessionID, pageData); err != nil {
+		logging.LogError("Failed to persist imported roster", err, "session_id", sessionID, "ip", ip)
+		http.Error(w, "Sitzung konnte nicht aktualisiert werden", http.StatusInternalServerError)
+		return
+	}
+
+	duration := time.Since(start)
+	logging.LogFileOperation("csv_import", fileHeader.Filename, fileHeader.Size, duration, true,
+		"session_id", sessionID,
+		"ip", ip,
+		"imported", imported,
+		"errors", len(importErrors))
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ImportResponse{Imported: imported, Skipped: len(importErrors), Errors: importErrors}); err != nil {
+		logging.LogError("Failed to encode CSV import response", err, "session_id", sessionID, "ip", ip)
+	}
+}
+
+// HandleStudentImportExcel is the Excel counterpart of
+// HandleStudentImportCSV: it reads the first sheet of the uploaded .xlsx
+// file via excelize's GetRows and runs the same roster validation.
+func HandleStudentImportExcel(w http.ResponseWriter, r *http.Request, sessionStore session.Store) {
+	start := time.Now()
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := getSessionIDFromCookie(r)
+	ip := security.GetClientIP(r)
+	if sessionID == "" {
+		http.Error(w, "Keine Session gefunden", http.StatusBadRequest)
+		return
+	}
+
+	pageData, _, err := sessionStore.GetContext(r.Context(), sessionID)
+	if err != nil {
+		logging.LogWarn("Session lookup aborted", "session_id", sessionID, "ip", ip, "error", err.Error())
+		http.Error(w, "Sitzungszugriff fehlgeschlagen", http.StatusBadGateway)
+		return
+	}
+
+	if err := r.ParseMultipartForm(models.MaxFileSize); err != nil {
+		http.Error(w, "Ungültige Upload-Anfrage", http.StatusBadRequest)
+		return
+	}
+
+	file, fileHeader, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Keine Datei hochgeladen", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if err := validateImportUpload(fileHeader, ".xlsx"); err != nil {
+		logging.LogSecurityEvent("Invalid roster upload attempted", "medium",
+			"filename", fileHeader.Filename,
+			"size", fileHeader.Size,
+			"error", err.Error())
+		http.Error(w, fmt.Sprintf("Ungültige Datei: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	f, err := excelize.OpenReader(file)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Excel-Datei konnte nicht gelesen werden: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		http.Error(w, "Excel-Datei enthält kein Arbeitsblatt", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := f.GetRows(sheets[0])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Arbeitsblatt konnte nicht gelesen werden: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	hasMaxPoints := pageData.HasResults
+	var maxPoints float64
+	if hasMaxPoints {
+		maxPoints = float64(pageData.MaxPoints)
+	}
+
+	students, importErrors := parseRosterRows(rows, maxPoints, hasMaxPoints)
+	importErrors, imported := appendRosterStudents(&pageData, students, importErrors)
+	recordUploadOutcomes(imported, len(importErrors))
+
+	recalculate(&pageData)
+
+	if err := sessionStore.SetContext(r.Context(), sessionID, pageData); err != nil {
+		logging.LogError("Failed to persist imported roster", err, "session_id", sessionID, "ip", ip)
+		http.Error(w, "Sitzung konnte nicht aktualisiert werden", http.StatusInternalServerError)
+		return
+	}
+
+	duration := time.Since(start)
+	logging.LogFileOperation("excel_import", fileHeader.Filename, fileHeader.Size, duration, true,
+		"session_id", sessionID,
+		"ip", ip,
+		"imported", imported,
+		"errors", len(importErrors))
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ImportResponse{Imported: imported, Skipped: len(importErrors), Errors: importErrors}); err != nil {
+		logging.LogError("Failed to encode Excel import response", err, "session_id", sessionID, "ip", ip)
+	}
+}
@@ -0,0 +1,227 @@
+package uploads
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/payback159/notenschluessel/pkg/models"
+	"github.com/xuri/excelize/v2"
+)
+
+// newMultipartUpload builds a POST request with a single "file" field
+// containing content, the same shape the roster import handlers expect.
+func newMultipartUpload(t *testing.T, url, filename string, content []byte) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		t.Fatalf("write part: %v", err)
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatalf("close writer: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, url, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// --- HandleStudentImportCSV ---
+
+func TestHandleStudentImportCSV_InternationalDialect(t *testing.T) {
+	sid := "sid-import-csv-intl"
+	store := newTestStore(sid, models.PageData{})
+
+	csvContent := "Name,Punkte\nAlice,90.5\nBob,60\n"
+	req := newMultipartUpload(t, "/api/students/import-csv", "roster.csv", []byte(csvContent))
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+	w := httptest.NewRecorder()
+
+	HandleStudentImportCSV(w, req, store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"imported":2`) {
+		t.Errorf("expected 2 imported, got %s", w.Body.String())
+	}
+
+	data, _ := store.Get(sid)
+	if len(data.Students) != 2 {
+		t.Fatalf("expected 2 students stored, got %d", len(data.Students))
+	}
+	if data.Students[0].Points != 90.5 {
+		t.Errorf("expected 90.5 points, got %v", data.Students[0].Points)
+	}
+}
+
+func TestHandleStudentImportCSV_GermanDialect(t *testing.T) {
+	sid := "sid-import-csv-de"
+	store := newTestStore(sid, models.PageData{})
+
+	csvContent := "Name;Punkte\nAlice;90,5\nBob;60,0\n"
+	req := newMultipartUpload(t, "/api/students/import-csv", "roster.csv", []byte(csvContent))
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+	w := httptest.NewRecorder()
+
+	HandleStudentImportCSV(w, req, store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	data, _ := store.Get(sid)
+	if len(data.Students) != 2 {
+		t.Fatalf("expected 2 students stored, got %d", len(data.Students))
+	}
+	if data.Students[0].Points != 90.5 {
+		t.Errorf("expected comma-decimal 90,5 parsed as 90.5, got %v", data.Students[0].Points)
+	}
+}
+
+func TestHandleStudentImportCSV_ReportsRowErrors(t *testing.T) {
+	sid := "sid-import-csv-errors"
+	store := newTestStore(sid, models.PageData{})
+
+	csvContent := "Name,Punkte\nAlice,90\nAlice,80\n,70\nBob,notanumber\n"
+	req := newMultipartUpload(t, "/api/students/import-csv", "roster.csv", []byte(csvContent))
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+	w := httptest.NewRecorder()
+
+	HandleStudentImportCSV(w, req, store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"imported":1`) {
+		t.Errorf("expected only Alice's first row to import, got %s", w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"skipped":3`) {
+		t.Errorf("expected 3 row errors, got %s", w.Body.String())
+	}
+}
+
+func TestHandleStudentImportCSV_RejectsPointsAboveMax(t *testing.T) {
+	sid := "sid-import-csv-maxpoints"
+	store := newTestStore(sid, models.PageData{HasResults: true, MaxPoints: 50})
+
+	csvContent := "Name,Punkte\nAlice,90\nBob,40\n"
+	req := newMultipartUpload(t, "/api/students/import-csv", "roster.csv", []byte(csvContent))
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+	w := httptest.NewRecorder()
+
+	HandleStudentImportCSV(w, req, store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	data, _ := store.Get(sid)
+	if len(data.Students) != 1 || data.Students[0].Name != "Bob" {
+		t.Errorf("expected only Bob to import, got %+v", data.Students)
+	}
+}
+
+func TestHandleStudentImportCSV_WrongExtension(t *testing.T) {
+	sid := "sid-import-csv-ext"
+	store := newTestStore(sid, models.PageData{})
+
+	req := newMultipartUpload(t, "/api/students/import-csv", "roster.txt", []byte("Name,Punkte\nAlice,90\n"))
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+	w := httptest.NewRecorder()
+
+	HandleStudentImportCSV(w, req, store)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want 400, got %d", w.Code)
+	}
+}
+
+func TestHandleStudentImportCSV_NoSession(t *testing.T) {
+	store := newTestStore("other-session", models.PageData{})
+
+	req := newMultipartUpload(t, "/api/students/import-csv", "roster.csv", []byte("Name,Punkte\nAlice,90\n"))
+	w := httptest.NewRecorder()
+
+	HandleStudentImportCSV(w, req, store)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want 400, got %d", w.Code)
+	}
+}
+
+// --- HandleStudentImportExcel ---
+
+func newTestXLSX(t *testing.T, rows [][]string) []byte {
+	t.Helper()
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := f.GetSheetName(0)
+	for i, row := range rows {
+		for j, val := range row {
+			cell, err := excelize.CoordinatesToCellName(j+1, i+1)
+			if err != nil {
+				t.Fatalf("CoordinatesToCellName: %v", err)
+			}
+			f.SetCellValue(sheet, cell, val)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatalf("write xlsx: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestHandleStudentImportExcel_WithData(t *testing.T) {
+	sid := "sid-import-excel"
+	store := newTestStore(sid, models.PageData{})
+
+	xlsx := newTestXLSX(t, [][]string{
+		{"Name", "Punkte"},
+		{"Alice", "90"},
+		{"Bob", "60"},
+	})
+
+	req := newMultipartUpload(t, "/api/students/import-excel", "roster.xlsx", xlsx)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+	w := httptest.NewRecorder()
+
+	HandleStudentImportExcel(w, req, store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	data, _ := store.Get(sid)
+	if len(data.Students) != 2 {
+		t.Fatalf("expected 2 students stored, got %d", len(data.Students))
+	}
+}
+
+func TestHandleStudentImportExcel_WrongExtension(t *testing.T) {
+	sid := "sid-import-excel-ext"
+	store := newTestStore(sid, models.PageData{})
+
+	req := newMultipartUpload(t, "/api/students/import-excel", "roster.csv", []byte("not an xlsx"))
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+	w := httptest.NewRecorder()
+
+	HandleStudentImportExcel(w, req, store)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,288 @@
+// Package uploads handles bulk ingestion of student data, complementing
+// pkg/downloads' export handlers with the inverse operation.
+package uploads
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/payback159/notenschluessel/pkg/calculator"
+	"github.com/payback159/notenschluessel/pkg/logging"
+	"github.com/payback159/notenschluessel/pkg/metrics"
+	"github.com/payback159/notenschluessel/pkg/models"
+	"github.com/payback159/notenschluessel/pkg/security"
+	"github.com/payback159/notenschluessel/pkg/session"
+)
+
+// studentInput is the wire shape accepted by the batch import endpoint.
+type studentInput struct {
+	Name   string  `json:"name"`
+	Points float64 `json:"points"`
+}
+
+// BatchResult reports the outcome of importing a single row, so partial
+// failures are surfaced instead of aborting the whole batch.
+type BatchResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// getSessionIDFromCookie reads the session_id cookie set by
+// handlers.HandleCalculation.
+func getSessionIDFromCookie(r *http.Request) string {
+	cookie, err := r.Cookie("session_id")
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// validateStudentInput mirrors calculator.ParseCSVFile's row validation so
+// imported students are held to the same bounds regardless of entry point.
+func validateStudentInput(in studentInput) (models.Student, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return models.Student{}, fmt.Errorf("name is required")
+	}
+	if len(name) > models.MaxNameLength {
+		return models.Student{}, fmt.Errorf("name exceeds %d characters", models.MaxNameLength)
+	}
+	if in.Points < 0 || in.Points > 1000 {
+		return models.Student{}, fmt.Errorf("points out of range (0-1000)")
+	}
+
+	return models.Student{Name: security.SanitizeName(name), Points: in.Points}, nil
+}
+
+// decodeJSONArray reads a JSON array of studentInput, e.g. the body of a
+// request without an ndjson Content-Type.
+func decodeJSONArray(r io.Reader) ([]studentInput, error) {
+	var inputs []studentInput
+	if err := json.NewDecoder(r).Decode(&inputs); err != nil {
+		return nil, err
+	}
+	if len(inputs) > models.MaxStudents {
+		return nil, fmt.Errorf("batch exceeds maximum of %d students", models.MaxStudents)
+	}
+	return inputs, nil
+}
+
+// decodeNDJSON reads one JSON object per line, so large classes can be
+// streamed without buffering the whole body as a single array.
+func decodeNDJSON(r io.Reader) ([]studentInput, error) {
+	var inputs []studentInput
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var in studentInput
+		if err := json.Unmarshal([]byte(line), &in); err != nil {
+			return nil, fmt.Errorf("line %d: %w", len(inputs)+1, err)
+		}
+		inputs = append(inputs, in)
+
+		if len(inputs) > models.MaxStudents {
+			return nil, fmt.Errorf("batch exceeds maximum of %d students", models.MaxStudents)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return inputs, nil
+}
+
+// recalculate re-derives each student's Grade and the session's average
+// once the student list has changed, provided grade bounds already exist
+// for the session (set by the initial calculation).
+func recalculate(pageData *models.PageData) {
+	pageData.HasStudents = len(pageData.Students) > 0
+	if pageData.HasResults && len(pageData.GradeBounds) > 0 {
+		pageData.Students = calculator.AssignGrades(pageData.Students, pageData.GradeBounds)
+		pageData.AverageGrade = calculator.CalculateAverageGrade(pageData.Students)
+		stats := calculator.ClassStatistics(pageData.Students, pageData.GradeBounds)
+		pageData.Stats = &stats
+	}
+}
+
+// HandleStudentsBatchImport accepts a JSON array of {name, points} objects,
+// or, with Content-Type: application/x-ndjson, a newline-delimited stream
+// of the same objects (for streaming large classes up to MaxStudents),
+// and appends validated rows to the session's student list.
+func HandleStudentsBatchImport(w http.ResponseWriter, r *http.Request, sessionStore session.Store) {
+	start := time.Now()
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := getSessionIDFromCookie(r)
+	ip := security.GetClientIP(r)
+	if sessionID == "" {
+		http.Error(w, "Keine Session gefunden", http.StatusBadRequest)
+		return
+	}
+
+	pageData, _, err := sessionStore.GetContext(r.Context(), sessionID)
+	if err != nil {
+		logging.LogWarn("Session lookup aborted", "session_id", sessionID, "ip", ip, "error", err.Error())
+		http.Error(w, "Sitzungszugriff fehlgeschlagen", http.StatusBadGateway)
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, models.MaxFileSize)
+
+	var inputs []studentInput
+	if strings.Contains(r.Header.Get("Content-Type"), "ndjson") {
+		inputs, err = decodeNDJSON(body)
+	} else {
+		inputs, err = decodeJSONArray(body)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Ungültige Eingabedaten: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	results := make([]BatchResult, 0, len(inputs))
+	imported := 0
+	for i, in := range inputs {
+		if len(pageData.Students) >= models.MaxStudents {
+			results = append(results, BatchResult{Index: i, Status: "error", Error: "maximum student limit reached"})
+			metrics.RecordUpload("error")
+			continue
+		}
+
+		student, err := validateStudentInput(in)
+		if err != nil {
+			results = append(results, BatchResult{Index: i, Status: "error", Error: err.Error()})
+			metrics.RecordUpload("error")
+			continue
+		}
+
+		pageData.Students = append(pageData.Students, student)
+		results = append(results, BatchResult{Index: i, Status: "ok"})
+		metrics.RecordUpload("ok")
+		imported++
+	}
+
+	recalculate(&pageData)
+
+	if err := sessionStore.SetContext(r.Context(), sessionID, pageData); err != nil {
+		logging.LogError("Failed to persist imported students", err, "session_id", sessionID, "ip", ip)
+		http.Error(w, "Sitzung konnte nicht aktualisiert werden", http.StatusInternalServerError)
+		return
+	}
+
+	duration := time.Since(start)
+	logging.LogInfo("Batch student import completed",
+		"session_id", sessionID,
+		"ip", ip,
+		"submitted", len(inputs),
+		"imported", imported,
+		"duration_ms", duration.Milliseconds())
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		logging.LogError("Failed to encode batch import response", err, "session_id", sessionID, "ip", ip)
+	}
+}
+
+// BatchDeleteRequest selects students to remove from the session, by name
+// or by index into the current Students slice.
+type BatchDeleteRequest struct {
+	Names   []string `json:"names"`
+	Indexes []int    `json:"indexes"`
+}
+
+// HandleStudentsBatchDelete removes the named or indexed students from the
+// session's student list.
+func HandleStudentsBatchDelete(w http.ResponseWriter, r *http.Request, sessionStore session.Store) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := getSessionIDFromCookie(r)
+	ip := security.GetClientIP(r)
+	if sessionID == "" {
+		http.Error(w, "Keine Session gefunden", http.StatusBadRequest)
+		return
+	}
+
+	pageData, exists, err := sessionStore.GetContext(r.Context(), sessionID)
+	if err != nil || !exists {
+		http.Error(w, "Keine Daten zum Löschen verfügbar", http.StatusBadRequest)
+		return
+	}
+
+	var req BatchDeleteRequest
+	body := http.MaxBytesReader(w, r.Body, models.MaxFileSize)
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Ungültige Eingabedaten: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	deleteIndexes := make(map[int]bool, len(req.Indexes))
+	for _, idx := range req.Indexes {
+		if idx >= 0 && idx < len(pageData.Students) {
+			deleteIndexes[idx] = true
+		}
+	}
+	deleteNames := make(map[string]bool, len(req.Names))
+	for _, name := range req.Names {
+		deleteNames[name] = true
+	}
+
+	remaining := pageData.Students[:0]
+	removed := 0
+	for i, student := range pageData.Students {
+		if deleteIndexes[i] || deleteNames[student.Name] {
+			removed++
+			continue
+		}
+		remaining = append(remaining, student)
+	}
+	pageData.Students = remaining
+
+	recalculate(&pageData)
+
+	if err := sessionStore.SetContext(r.Context(), sessionID, pageData); err != nil {
+		logging.LogError("Failed to persist student deletion", err, "session_id", sessionID, "ip", ip)
+		http.Error(w, "Sitzung konnte nicht aktualisiert werden", http.StatusInternalServerError)
+		return
+	}
+
+	logging.LogInfo("Batch student delete completed",
+		"session_id", sessionID,
+		"ip", ip,
+		"removed", removed,
+		"remaining", len(pageData.Students))
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]int{"removed": removed, "remaining": len(pageData.Students)}); err != nil {
+		logging.LogError("Failed to encode batch delete response", err, "session_id", sessionID, "ip", ip)
+	}
+}
+
+// recordUploadOutcomes records one notenschluessel_uploads_total observation
+// per validated row, so file-based roster imports (CSV/Excel) show up on
+// the same counter as the JSON batch import API above.
+func recordUploadOutcomes(ok, failed int) {
+	for i := 0; i < ok; i++ {
+		metrics.RecordUpload("ok")
+	}
+	for i := 0; i < failed; i++ {
+		metrics.RecordUpload("error")
+	}
+}
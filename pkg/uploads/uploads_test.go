@@ -0,0 +1,138 @@
+package uploads
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/payback159/notenschluessel/pkg/logging"
+	"github.com/payback159/notenschluessel/pkg/models"
+	"github.com/payback159/notenschluessel/pkg/session"
+)
+
+func init() {
+	logging.InitLogger()
+}
+
+func newTestStore(sessionID string, data models.PageData) session.Store {
+	store := session.NewStore()
+	store.Set(sessionID, data)
+	return store
+}
+
+// --- HandleStudentsBatchImport ---
+
+func TestHandleStudentsBatchImport_JSONArray(t *testing.T) {
+	sid := "sid-import-json"
+	store := newTestStore(sid, models.PageData{})
+
+	body := `[{"name":"Alice","points":90},{"name":"Bob","points":60}]`
+	req := httptest.NewRequest(http.MethodPost, "/api/students/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+	w := httptest.NewRecorder()
+
+	HandleStudentsBatchImport(w, req, store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"status":"ok"`) {
+		t.Errorf("expected ok results, got %s", w.Body.String())
+	}
+
+	data, _ := store.Get(sid)
+	if len(data.Students) != 2 {
+		t.Errorf("expected 2 students stored, got %d", len(data.Students))
+	}
+}
+
+func TestHandleStudentsBatchImport_NDJSON(t *testing.T) {
+	sid := "sid-import-ndjson"
+	store := newTestStore(sid, models.PageData{})
+
+	body := "{\"name\":\"Alice\",\"points\":90}\n{\"name\":\"Bob\",\"points\":60}\n"
+	req := httptest.NewRequest(http.MethodPost, "/api/students/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+	w := httptest.NewRecorder()
+
+	HandleStudentsBatchImport(w, req, store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	data, _ := store.Get(sid)
+	if len(data.Students) != 2 {
+		t.Errorf("expected 2 students stored, got %d", len(data.Students))
+	}
+}
+
+func TestHandleStudentsBatchImport_PartialFailure(t *testing.T) {
+	sid := "sid-import-partial"
+	store := newTestStore(sid, models.PageData{})
+
+	body := `[{"name":"Alice","points":90},{"name":"","points":60},{"name":"Eve","points":5000}]`
+	req := httptest.NewRequest(http.MethodPost, "/api/students/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+	w := httptest.NewRecorder()
+
+	HandleStudentsBatchImport(w, req, store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200 (partial failures reported in body), got %d", w.Code)
+	}
+
+	data, _ := store.Get(sid)
+	if len(data.Students) != 1 {
+		t.Errorf("expected only the valid row to be imported, got %d", len(data.Students))
+	}
+	if !strings.Contains(w.Body.String(), `"status":"error"`) {
+		t.Error("expected per-row error results for the invalid rows")
+	}
+}
+
+func TestHandleStudentsBatchImport_NoSession(t *testing.T) {
+	store := session.NewStore()
+	req := httptest.NewRequest(http.MethodPost, "/api/students/batch", strings.NewReader(`[]`))
+	w := httptest.NewRecorder()
+
+	HandleStudentsBatchImport(w, req, store)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want 400, got %d", w.Code)
+	}
+}
+
+// --- HandleStudentsBatchDelete ---
+
+func TestHandleStudentsBatchDelete_ByNameAndIndex(t *testing.T) {
+	sid := "sid-delete"
+	store := newTestStore(sid, models.PageData{
+		HasStudents: true,
+		Students: []models.Student{
+			{Name: "Alice", Points: 90},
+			{Name: "Bob", Points: 60},
+			{Name: "Carol", Points: 70},
+		},
+	})
+
+	body := `{"names":["Bob"],"indexes":[0]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/students/batch-delete", strings.NewReader(body))
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sid})
+	w := httptest.NewRecorder()
+
+	HandleStudentsBatchDelete(w, req, store)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	data, _ := store.Get(sid)
+	if len(data.Students) != 1 || data.Students[0].Name != "Carol" {
+		t.Errorf("expected only Carol to remain, got %+v", data.Students)
+	}
+}